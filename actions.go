@@ -0,0 +1,232 @@
+package gofpdf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Action specifies what a PDF viewer does in response to an event, such as
+// the opening of the document or of a page. It is used with SetOpenAction(),
+// SetDocumentAction() and SetPageAction(). Exactly one kind of action must be
+// specified.
+//
+// Page, if greater than zero, is the one-based number of the page to go to.
+// X and Y are the position on that page in the units established in New(),
+// and Fit determines how the page is displayed, as it does for
+// AddNamedDestination(); an empty value is treated as FitXYZ and FitRect is
+// not supported. Zoom, if greater than zero, is the magnification factor used
+// with FitXYZ, 1 being actual size.
+//
+// DestName goes to the destination defined with AddNamedDestination() under
+// that name, URI opens a web address and JavaScript runs JavaScript code.
+type Action struct {
+	Page       int
+	X, Y       float64
+	Fit        FitMode
+	Zoom       float64
+	DestName   string
+	URI        string
+	JavaScript string
+}
+
+// SetOpenAction specifies the action performed when the document is opened,
+// such as displaying a particular page at a particular zoom factor or running
+// JavaScript code. It replaces the initial zoom set with SetDisplayMode().
+//
+// The SetOpenAction() example demonstrates this method.
+func (f *Fpdf) SetOpenAction(action Action) {
+	if f.err != nil {
+		return
+	}
+	if f.actionCheck(&action) {
+		f.openAction = &action
+	}
+}
+
+// SetDocumentAction specifies the action performed when a document event
+// occurs. trigger is one of "WillClose", "WillSave", "DidSave", "WillPrint"
+// or "DidPrint". The "Will" events occur before the document is closed, saved
+// or printed, and the "Did" events after it has been saved or printed. Viewers
+// generally support only JavaScript actions for these events. Setting a
+// document action raises the PDF version of the document to 1.4.
+//
+// The SetOpenAction() example demonstrates this method.
+func (f *Fpdf) SetDocumentAction(trigger string, action Action) {
+	if f.err != nil {
+		return
+	}
+	keys := map[string]string{"WillClose": "WC", "WillSave": "WS", "DidSave": "DS",
+		"WillPrint": "WP", "DidPrint": "DP"}
+	key, ok := keys[trigger]
+	if !ok {
+		f.err = fmt.Errorf("invalid document action trigger \"%s\"", trigger)
+		return
+	}
+	if !f.actionCheck(&action) {
+		return
+	}
+	if f.docActions == nil {
+		f.docActions = make(map[string]Action)
+	}
+	f.docActions[key] = action
+	if f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+}
+
+// SetPageAction specifies the action performed when the current page is
+// opened or closed in a viewer. trigger is "Open" or "Close".
+//
+// The SetOpenAction() example demonstrates this method.
+func (f *Fpdf) SetPageAction(trigger string, action Action) {
+	if f.err != nil {
+		return
+	}
+	if f.page == 0 {
+		f.err = fmt.Errorf("a page must be added before setting its actions")
+		return
+	}
+	if trigger != "Open" && trigger != "Close" {
+		f.err = fmt.Errorf("invalid page action trigger \"%s\"", trigger)
+		return
+	}
+	if !f.actionCheck(&action) {
+		return
+	}
+	attr := f.pageAttrs[f.page]
+	if trigger == "Open" {
+		attr.openAction = &action
+	} else {
+		attr.closeAction = &action
+	}
+	f.pageAttrSet(f.page, attr)
+}
+
+// AddJavascript adds JavaScript code to the document under name. The
+// document-level scripts are run, in the order of their names, when the
+// document is opened; they typically define functions that are called by
+// actions. Adding a script under an existing name replaces that script.
+//
+// The SetOpenAction() example demonstrates this method.
+func (f *Fpdf) AddJavascript(name, script string) {
+	if f.javascripts == nil {
+		f.javascripts = make(map[string]string)
+	}
+	f.javascripts[name] = script
+}
+
+// actionCheck validates action and sets its default fit mode. It returns
+// false if the action is invalid.
+func (f *Fpdf) actionCheck(action *Action) bool {
+	kinds := 0
+	if action.Page != 0 {
+		kinds++
+	}
+	for _, s := range []string{action.DestName, action.URI, action.JavaScript} {
+		if s != "" {
+			kinds++
+		}
+	}
+	switch {
+	case kinds != 1:
+		f.err = fmt.Errorf("action must specify one of Page, DestName, URI and JavaScript")
+	case action.Page < 0:
+		f.err = fmt.Errorf("invalid action page %d", action.Page)
+	}
+	switch action.Fit {
+	case "":
+		action.Fit = FitXYZ
+	case FitXYZ, FitPage, FitWidth, FitHeight, FitBounds, FitBoundsWidth, FitBoundsHeight:
+	default:
+		f.err = fmt.Errorf("invalid action fit mode \"%s\"", action.Fit)
+	}
+	return f.err == nil
+}
+
+// actionsCheck verifies that the pages referred to by actions exist
+func (f *Fpdf) actionsCheck() {
+	count := len(f.pages) - 1
+	check := func(a *Action) {
+		if a != nil && a.Page > count && f.err == nil {
+			f.err = fmt.Errorf("action refers to page %d of a %d page document", a.Page, count)
+		}
+	}
+	check(f.openAction)
+	for _, a := range f.docActions {
+		check(&a)
+	}
+	for _, attr := range f.pageAttrs {
+		check(attr.openAction)
+		check(attr.closeAction)
+	}
+}
+
+// actionRemap returns a copy of action with its page renumbered by remap.
+// removed reports whether the page of the action was removed, in which case
+// the top of the substitute page is used.
+func actionRemap(action *Action, remap func(int) int, removed func(int) bool) *Action {
+	if action == nil || action.Page == 0 {
+		return action
+	}
+	a := *action
+	if removed(a.Page) {
+		a.Y = 0
+	}
+	a.Page = remap(a.Page)
+	return &a
+}
+
+// actionDict returns the PDF action dictionary for action
+func (f *Fpdf) actionDict(action Action) string {
+	switch {
+	case action.URI != "":
+		return sprintf("<</S /URI /URI %s>>", f.textstring(action.URI))
+	case action.JavaScript != "":
+		return sprintf("<</S /JavaScript /JS %s>>", f.textstring(action.JavaScript))
+	case action.DestName != "":
+		return sprintf("<</S /GoTo /D %s>>", f.textstring(action.DestName))
+	}
+	return sprintf("<</S /GoTo /D %s>>", f.destArray(namedDestType{page: action.Page,
+		x: action.X, y: action.Y, fit: action.Fit, zoom: action.Zoom}))
+}
+
+// putDocActions writes the /OpenAction and /AA entries of the catalog. It
+// returns false if there is no open action.
+func (f *Fpdf) putDocActions() bool {
+	if len(f.docActions) > 0 {
+		keys := make([]string, 0, len(f.docActions))
+		for key := range f.docActions {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var b fmtBuffer
+		b.printf("/AA <<")
+		for _, key := range keys {
+			b.printf("/%s %s", key, f.actionDict(f.docActions[key]))
+		}
+		b.printf(">>")
+		f.out(b.String())
+	}
+	if f.openAction == nil {
+		return false
+	}
+	f.outf("/OpenAction %s", f.actionDict(*f.openAction))
+	return true
+}
+
+// putPageActions writes the /AA entry of a page with the given attributes
+func (f *Fpdf) putPageActions(attr pageAttrType) {
+	if attr.openAction == nil && attr.closeAction == nil {
+		return
+	}
+	var b fmtBuffer
+	b.printf("/AA <<")
+	if attr.openAction != nil {
+		b.printf("/O %s", f.actionDict(*attr.openAction))
+	}
+	if attr.closeAction != nil {
+		b.printf("/C %s", f.actionDict(*attr.closeAction))
+	}
+	b.printf(">>")
+	f.out(b.String())
+}
@@ -0,0 +1,347 @@
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Annotation describes a markup annotation, such as a sticky note or a
+// highlight, that is added to a page with AddAnnotation(). PDF viewers
+// display annotations on top of the page content and typically let the user
+// review, reply to and print them.
+//
+// Type is one of "Text" (sticky note), "FreeText" (text displayed directly
+// on the page), "Highlight", "Underline", "StrikeOut", "Squiggly", "Square",
+// "Circle", "Line", "Polygon", "PolyLine", "Ink" or "Stamp".
+//
+// X, Y, Wd and Ht specify the rectangle of the annotation in the units
+// established in New(), with (X, Y) being its upper left corner. For Line,
+// Polygon, PolyLine and Ink annotations, the rectangle is computed from the
+// points if Wd and Ht are both zero.
+//
+// Contents is the text of the annotation, Author the name of its author and
+// Subject a short description of its topic. Color is the color of the icon,
+// border or markup; InteriorColor, if not nil, fills Square, Circle and
+// Polygon annotations and the closed line endings of Line and PolyLine
+// annotations. Opacity, between 0 and 1, applies to the appearance of the
+// annotation; zero is treated as fully opaque. CreationDate, if not zero, is
+// recorded with the annotation.
+//
+// Popup adds a pop-up window that displays Contents, and Open indicates that
+// the pop-up window, or the note of a Text annotation, is initially open.
+//
+// Name is the icon of a Text annotation, such as "Comment", "Note", "Help",
+// "Insert", "Key", "NewParagraph" or "Paragraph", or of a Stamp annotation,
+// such as "Approved", "Draft", "Confidential", "Final", "NotApproved" or
+// "ForComment".
+//
+// Points holds, for Highlight, Underline, StrikeOut and Squiggly annotations,
+// the upper left, upper right, lower left and lower right corners of each
+// marked up region; if empty, the rectangle of the annotation is marked up.
+// For Line annotations it holds the two end points, and for Polygon and
+// PolyLine annotations the vertices. InkList holds the strokes of an Ink
+// annotation. LineEndings specifies the start and end styles of Line and
+// PolyLine annotations: "None" (the default), "Square", "Circle", "Diamond",
+// "OpenArrow", "ClosedArrow", "Butt", "ROpenArrow", "RClosedArrow" or
+// "Slash". LineWidth, if greater than zero, is the width of the border or
+// line in user units.
+//
+// FontSize, in points, and Align, one of "L" (the default), "C" or "R",
+// determine the appearance of the text of a FreeText annotation.
+type Annotation struct {
+	Type          string
+	X, Y, Wd, Ht  float64
+	Contents      string
+	Author        string
+	Subject       string
+	Color         *RGBType
+	InteriorColor *RGBType
+	Opacity       float64
+	CreationDate  time.Time
+	Popup         bool
+	Open          bool
+	Name          string
+	Points        []PointType
+	InkList       [][]PointType
+	LineEndings   [2]string
+	LineWidth     float64
+	FontSize      float64
+	Align         string
+}
+
+// annotationType holds an annotation along with the object number assigned
+// to it when the document is output
+type annotationType struct {
+	Annotation
+	obj int
+}
+
+// AddAnnotation adds the markup annotation described by a to page number
+// page, or to the current page if page is -1. The page content is not
+// affected; viewers draw the annotation themselves. An annotation belongs to
+// its page and is removed along with it by DeletePage().
+//
+// Some annotations raise the PDF version of the document: to 1.4 for
+// Squiggly annotations and opacity, and to 1.5 for Polygon and PolyLine
+// annotations, subjects and creation dates.
+//
+// The AddAnnotation() example demonstrates this method.
+func (f *Fpdf) AddAnnotation(page int, a Annotation) {
+	if f.err != nil {
+		return
+	}
+	if page == -1 {
+		page = f.page
+	}
+	if page < 1 || page > f.PageCount() {
+		f.err = fmt.Errorf("invalid page %d for annotation", page)
+		return
+	}
+	version := "1.3"
+	switch a.Type {
+	case "Text", "FreeText", "Highlight", "Underline", "StrikeOut", "Square", "Circle", "Stamp":
+	case "Squiggly":
+		version = "1.4"
+	case "Line":
+		if len(a.Points) != 2 {
+			f.err = fmt.Errorf("line annotation requires two points")
+			return
+		}
+	case "Polygon", "PolyLine":
+		if len(a.Points) < 2 {
+			f.err = fmt.Errorf("%s annotation requires at least two points", strings.ToLower(a.Type))
+			return
+		}
+		version = "1.5"
+	case "Ink":
+		if len(a.InkList) == 0 {
+			f.err = fmt.Errorf("ink annotation requires at least one stroke")
+			return
+		}
+	default:
+		f.err = fmt.Errorf("invalid annotation type \"%s\"", a.Type)
+		return
+	}
+	switch a.Type {
+	case "Highlight", "Underline", "StrikeOut", "Squiggly":
+		if len(a.Points)%4 != 0 {
+			f.err = fmt.Errorf("annotation quadrilaterals require groups of four points")
+			return
+		}
+	}
+	for _, s := range a.LineEndings {
+		switch s {
+		case "", "None", "Square", "Circle", "Diamond", "OpenArrow", "ClosedArrow", "Butt",
+			"ROpenArrow", "RClosedArrow", "Slash":
+		default:
+			f.err = fmt.Errorf("invalid annotation line ending \"%s\"", s)
+			return
+		}
+	}
+	switch a.Align {
+	case "", "L", "C", "R":
+	default:
+		f.err = fmt.Errorf("invalid annotation alignment \"%s\"", a.Align)
+		return
+	}
+	if a.Opacity <= 0 || a.Opacity > 1 {
+		a.Opacity = 1
+	} else if a.Opacity < 1 && version < "1.4" {
+		version = "1.4"
+	}
+	if a.Subject != "" || !a.CreationDate.IsZero() {
+		version = "1.5"
+	}
+	if f.pdfVersion < version {
+		f.pdfVersion = version
+	}
+	if a.Wd == 0 && a.Ht == 0 {
+		a.X, a.Y, a.Wd, a.Ht = annotationBounds(a)
+	}
+	a.Points = append([]PointType{}, a.Points...)
+	a.InkList = append([][]PointType{}, a.InkList...)
+	f.pageAnnots[page] = append(f.pageAnnots[page], annotationType{Annotation: a})
+}
+
+// annotationBounds returns the rectangle enclosing the points of a, widened
+// by the line width and line endings
+func annotationBounds(a Annotation) (x, y, wd, ht float64) {
+	var list []PointType
+	list = append(list, a.Points...)
+	for _, stroke := range a.InkList {
+		list = append(list, stroke...)
+	}
+	if len(list) == 0 {
+		return a.X, a.Y, a.Wd, a.Ht
+	}
+	x0, y0, x1, y1 := list[0].X, list[0].Y, list[0].X, list[0].Y
+	for _, pt := range list[1:] {
+		x0, x1 = math.Min(x0, pt.X), math.Max(x1, pt.X)
+		y0, y1 = math.Min(y0, pt.Y), math.Max(y1, pt.Y)
+	}
+	margin := a.LineWidth
+	if margin <= 0 {
+		margin = 1
+	}
+	if a.LineEndings[0] != "" || a.LineEndings[1] != "" {
+		margin *= 6
+	}
+	return x0 - margin, y0 - margin, x1 - x0 + 2*margin, y1 - y0 + 2*margin
+}
+
+// annotationRefs assigns object numbers, starting with obj, to the
+// annotations of page n and returns their references for the /Annots array
+// of the page along with the next free object number
+func (f *Fpdf) annotationRefs(n, obj int) (refs string, next int) {
+	var b fmtBuffer
+	for j := range f.pageAnnots[n] {
+		an := &f.pageAnnots[n][j]
+		an.obj = obj
+		b.printf("%d 0 R ", obj)
+		obj++
+		if an.Popup {
+			b.printf("%d 0 R ", obj)
+			obj++
+		}
+	}
+	return b.String(), obj
+}
+
+// putAnnotations writes the annotation objects of all pages. The object
+// numbers were assigned by annotationRefs() as the pages were written, and
+// pageObj holds the object number of each page.
+func (f *Fpdf) putAnnotations(pageObj []int) {
+	for n := 1; n < len(f.pageAnnots); n++ {
+		h := f.pageHeightPt(n)
+		pt := func(p PointType) string {
+			return sprintf("%.2f %.2f", p.X*f.k, h-p.Y*f.k)
+		}
+		clr := func(c *RGBType) string {
+			return sprintf("[%.3f %.3f %.3f]", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+		}
+		for _, an := range f.pageAnnots[n] {
+			x0, y0 := an.X*f.k, h-(an.Y+an.Ht)*f.k
+			x1, y1 := (an.X+an.Wd)*f.k, h-an.Y*f.k
+			f.newobj()
+			if f.n != an.obj {
+				f.err = fmt.Errorf("annotation object %d written as %d", an.obj, f.n)
+				return
+			}
+			var b fmtBuffer
+			b.printf("<</Type /Annot /Subtype /%s /Rect [%.2f %.2f %.2f %.2f] /P %d 0 R",
+				an.Type, x0, y0, x1, y1, pageObj[n])
+			if an.Contents != "" {
+				b.printf(" /Contents %s", f.textstring(utf8toutf16(an.Contents)))
+			}
+			if an.Author != "" {
+				b.printf(" /T %s", f.textstring(utf8toutf16(an.Author)))
+			}
+			if an.Subject != "" {
+				b.printf(" /Subj %s", f.textstring(utf8toutf16(an.Subject)))
+			}
+			if !an.CreationDate.IsZero() {
+				tmStr := f.textstring("D:" + an.CreationDate.Format("20060102150405"))
+				b.printf(" /CreationDate %s /M %s", tmStr, tmStr)
+			}
+			b.printf(" /F 4")
+			if an.Color != nil {
+				b.printf(" /C %s", clr(an.Color))
+			}
+			if an.InteriorColor != nil {
+				switch an.Type {
+				case "Square", "Circle", "Polygon", "Line", "PolyLine":
+					b.printf(" /IC %s", clr(an.InteriorColor))
+				}
+			}
+			if an.Opacity < 1 {
+				b.printf(" /CA %.3f", an.Opacity)
+			}
+			if an.LineWidth > 0 {
+				b.printf(" /BS <</W %.2f>>", an.LineWidth*f.k)
+			}
+			switch an.Type {
+			case "Text", "Stamp":
+				if an.Name != "" {
+					b.printf(" /Name /%s", an.Name)
+				}
+				if an.Type == "Text" && an.Open {
+					b.printf(" /Open true")
+				}
+			case "FreeText":
+				size := an.FontSize
+				if size <= 0 {
+					size = 12
+				}
+				q := 0
+				switch an.Align {
+				case "C":
+					q = 1
+				case "R":
+					q = 2
+				}
+				b.printf(" /DA (/Helv %.2f Tf 0 g) /Q %d", size, q)
+			case "Highlight", "Underline", "StrikeOut", "Squiggly":
+				quads := an.Points
+				if len(quads) == 0 {
+					quads = []PointType{{an.X, an.Y}, {an.X + an.Wd, an.Y},
+						{an.X, an.Y + an.Ht}, {an.X + an.Wd, an.Y + an.Ht}}
+				}
+				list := make([]string, len(quads))
+				for j, p := range quads {
+					list[j] = pt(p)
+				}
+				b.printf(" /QuadPoints [%s]", strings.Join(list, " "))
+			case "Line", "Polygon", "PolyLine":
+				list := make([]string, len(an.Points))
+				for j, p := range an.Points {
+					list[j] = pt(p)
+				}
+				if an.Type == "Line" {
+					b.printf(" /L [%s]", strings.Join(list, " "))
+				} else {
+					b.printf(" /Vertices [%s]", strings.Join(list, " "))
+				}
+				if an.Type != "Polygon" && (an.LineEndings[0] != "" || an.LineEndings[1] != "") {
+					le := an.LineEndings
+					for j := range le {
+						if le[j] == "" {
+							le[j] = "None"
+						}
+					}
+					b.printf(" /LE [/%s /%s]", le[0], le[1])
+				}
+			case "Ink":
+				strokes := make([]string, len(an.InkList))
+				for j, stroke := range an.InkList {
+					list := make([]string, len(stroke))
+					for k, p := range stroke {
+						list[k] = pt(p)
+					}
+					strokes[j] = "[" + strings.Join(list, " ") + "]"
+				}
+				b.printf(" /InkList [%s]", strings.Join(strokes, " "))
+			}
+			if an.Popup {
+				b.printf(" /Popup %d 0 R", an.obj+1)
+			}
+			b.printf(">>")
+			f.out(b.String())
+			f.out("endobj")
+			if an.Popup {
+				// Place the pop-up window to the right of the annotation, or to
+				// its left if there is not enough room
+				const popWd, popHt = 180, 120
+				px := x1
+				if pw := f.pageWidthPt(n); px+popWd > pw {
+					px = math.Max(0, x0-popWd)
+				}
+				f.newobj()
+				f.outf("<</Type /Annot /Subtype /Popup /Rect [%.2f %.2f %.2f %.2f] /Parent %d 0 R /Open %v>>",
+					px, y1-popHt, px+popWd, y1, an.obj, an.Open)
+				f.out("endobj")
+			}
+		}
+	}
+}
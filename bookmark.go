@@ -0,0 +1,152 @@
+package gofpdf
+
+import (
+	"fmt"
+)
+
+// BookmarkOptions specifies the appearance and target of a bookmark added
+// with BookmarkWithOptions().
+//
+// Color, if not nil, is the color of the bookmark title. Bold and Italic set
+// the style of the title. Open indicates that the bookmark's children are
+// initially visible in the outline; otherwise they are hidden until the user
+// expands the bookmark. Viewers may ignore these settings.
+//
+// Page is the one-based number of the destination page; zero indicates the
+// current page. X and Y are the destination position on that page in the
+// units established in New(); a Y value of -1 indicates the current vertical
+// position. Fit determines how the destination page is displayed, as it does
+// for AddNamedDestination(); an empty value is treated as FitXYZ and FitRect
+// is not supported.
+//
+// Instead of a page, the bookmark can target the destination defined with
+// AddNamedDestination() under DestName, open the web address URI, or run the
+// JavaScript code in JavaScript. Only one of these may be specified.
+type BookmarkOptions struct {
+	Color      *RGBType
+	Bold       bool
+	Italic     bool
+	Open       bool
+	Page       int
+	X, Y       float64
+	Fit        FitMode
+	DestName   string
+	URI        string
+	JavaScript string
+}
+
+// outlineOptType holds the settings of a bookmark added with
+// BookmarkWithOptions()
+type outlineOptType struct {
+	color      *RGBType
+	flags      int
+	open       bool
+	x          float64
+	fit        FitMode
+	destName   string
+	uri        string
+	javascript string
+}
+
+// BookmarkWithOptions adds a bookmark like Bookmark() does, with the
+// appearance, initial state and target specified in opts. txtStr is the title
+// of the bookmark and level its level in the outline, 0 being the top level.
+//
+// The BookmarkWithOptions() example demonstrates this method.
+func (f *Fpdf) BookmarkWithOptions(txtStr string, level int, opts BookmarkOptions) {
+	if f.err != nil {
+		return
+	}
+	page := opts.Page
+	if page == 0 {
+		page = f.page
+	}
+	if page < 1 || page > f.PageCount() {
+		f.err = fmt.Errorf("invalid page %d for bookmark \"%s\"", opts.Page, txtStr)
+		return
+	}
+	actions := 0
+	for _, s := range []string{opts.DestName, opts.URI, opts.JavaScript} {
+		if s != "" {
+			actions++
+		}
+	}
+	if actions > 1 {
+		f.err = fmt.Errorf("bookmark \"%s\" may have only one of DestName, URI and JavaScript", txtStr)
+		return
+	}
+	switch opts.Fit {
+	case "":
+		opts.Fit = FitXYZ
+	case FitXYZ, FitPage, FitWidth, FitHeight, FitBounds, FitBoundsWidth, FitBoundsHeight:
+	default:
+		f.err = fmt.Errorf("invalid fit mode \"%s\" for bookmark \"%s\"", opts.Fit, txtStr)
+		return
+	}
+	opt := &outlineOptType{
+		open:       opts.Open,
+		x:          opts.X,
+		fit:        opts.Fit,
+		destName:   opts.DestName,
+		uri:        opts.URI,
+		javascript: opts.JavaScript,
+	}
+	if opts.Color != nil {
+		clr := *opts.Color
+		opt.color = &clr
+	}
+	if opts.Italic {
+		opt.flags |= 1
+	}
+	if opts.Bold {
+		opt.flags |= 2
+	}
+	if (opt.color != nil || opt.flags != 0) && f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+	f.Bookmark(txtStr, level, opts.Y)
+	o := &f.outlines[len(f.outlines)-1]
+	o.p = page
+	o.opt = opt
+}
+
+// outlineVisible returns the number of descendants of outline i that are
+// visible when i is open
+func (f *Fpdf) outlineVisible(i int) (count int) {
+	for c := f.outlines[i].first; c != -1; c = f.outlines[c].next {
+		count++
+		if opt := f.outlines[c].opt; opt != nil && opt.open {
+			count += f.outlineVisible(c)
+		}
+	}
+	return
+}
+
+// putBookmarkOpts writes the destination or action, color, style and count of
+// outline i, which was added with BookmarkWithOptions()
+func (f *Fpdf) putBookmarkOpts(i int) {
+	o := f.outlines[i]
+	opt := o.opt
+	switch {
+	case opt.uri != "":
+		f.outf("/A <</S /URI /URI %s>>", f.textstring(opt.uri))
+	case opt.javascript != "":
+		f.outf("/A <</S /JavaScript /JS %s>>", f.textstring(opt.javascript))
+	case opt.destName != "":
+		f.outf("/Dest %s", f.textstring(opt.destName))
+	default:
+		f.outf("/Dest %s", f.destArray(namedDestType{page: o.p, x: opt.x, y: o.y, fit: opt.fit}))
+	}
+	if opt.color != nil {
+		f.outf("/C [%.3f %.3f %.3f]", float64(opt.color.R)/255, float64(opt.color.G)/255,
+			float64(opt.color.B)/255)
+	}
+	if opt.flags != 0 {
+		f.outf("/F %d", opt.flags)
+	}
+	count := f.outlineVisible(i)
+	if !opt.open {
+		count = -count
+	}
+	f.outf("/Count %d>>", count)
+}
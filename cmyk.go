@@ -0,0 +1,81 @@
+package gofpdf
+
+// cmykColorValue returns the color operator string for the CMYK color with
+// components c, m, y and k (0 - 100) and operator opStr
+func cmykColorValue(c, m, y, k byte, opStr string) (clr cmykColorType, str string) {
+	clr = cmykColorType{c: byteBound(c), m: byteBound(m), y: byteBound(y), k: byteBound(k)}
+	str = sprintf("%.3f %.3f %.3f %.3f %s", float64(clr.c)/100, float64(clr.m)/100,
+		float64(clr.y)/100, float64(clr.k)/100, opStr)
+	return
+}
+
+// SetDrawCMYK sets the current draw color to the ink-based color with cyan,
+// magenta, yellow and black components c, m, y and k. The components specify
+// percentages ranging from 0 to 100. Values above this are quietly capped to
+// 100. Like SetDrawColor(), this method can be called before the first page is
+// created and the value is retained from page to page.
+//
+// The SetDrawCMYK() example demonstrates this method.
+func (f *Fpdf) SetDrawCMYK(c, m, y, k byte) {
+	f.color.draw.mode = colorModeCMYK
+	f.color.draw.cmyk, f.color.draw.str = cmykColorValue(c, m, y, k, "K")
+	if f.page > 0 {
+		f.out(f.color.draw.str)
+	}
+}
+
+// SetFillCMYK sets the current fill color to the ink-based color with cyan,
+// magenta, yellow and black components c, m, y and k, specified as with
+// SetDrawCMYK().
+//
+// The SetDrawCMYK() example demonstrates this method.
+func (f *Fpdf) SetFillCMYK(c, m, y, k byte) {
+	f.color.fill.mask = 0
+	f.color.fill.mode = colorModeCMYK
+	f.color.fill.cmyk, f.color.fill.str = cmykColorValue(c, m, y, k, "k")
+	f.colorFlag = f.color.fill.str != f.color.text.str
+	if f.page > 0 {
+		f.out(f.color.fill.str)
+	}
+}
+
+// SetTextCMYK sets the current text color to the ink-based color with cyan,
+// magenta, yellow and black components c, m, y and k, specified as with
+// SetDrawCMYK().
+//
+// The SetDrawCMYK() example demonstrates this method.
+func (f *Fpdf) SetTextCMYK(c, m, y, k byte) {
+	f.color.text.mode = colorModeCMYK
+	f.color.text.cmyk, f.color.text.str = cmykColorValue(c, m, y, k, "k")
+	f.colorFlag = f.color.fill.str != f.color.text.str
+}
+
+// GetDrawCMYK returns the current draw color as CMYK percentages (0 - 100). If
+// the current draw color was not set with SetDrawCMYK(), zero values are
+// returned.
+func (f *Fpdf) GetDrawCMYK() (c, m, y, k byte) {
+	return f.color.draw.cmykValues()
+}
+
+// GetFillCMYK returns the current fill color as CMYK percentages (0 - 100). If
+// the current fill color was not set with SetFillCMYK(), zero values are
+// returned.
+func (f *Fpdf) GetFillCMYK() (c, m, y, k byte) {
+	return f.color.fill.cmykValues()
+}
+
+// GetTextCMYK returns the current text color as CMYK percentages (0 - 100). If
+// the current text color was not set with SetTextCMYK(), zero values are
+// returned.
+func (f *Fpdf) GetTextCMYK() (c, m, y, k byte) {
+	return f.color.text.cmykValues()
+}
+
+// cmykValues returns the CMYK components of clr, or zero values if clr is not
+// a CMYK color
+func (clr colorType) cmykValues() (c, m, y, k byte) {
+	if clr.mode == colorModeCMYK {
+		c, m, y, k = clr.cmyk.c, clr.cmyk.m, clr.cmyk.y, clr.cmyk.k
+	}
+	return
+}
@@ -33,14 +33,56 @@ const (
 
 type blendModeType struct {
 	strokeStr, fillStr, modeStr string
+	overprint                   overprintType
+	intentStr                   string // rendering intent, empty if not set
 	objNum                      int
 }
 
+// overprintType holds the overprint settings of an ExtGState entry
+type overprintType struct {
+	set          bool // overprint has been set with SetOverprint()
+	stroke, fill bool
+	mode         int
+}
+
 type gradientType struct {
-	tp                int // 2: linear, 3: radial
+	tp                int // 2: linear, 3: radial, 4 to 7: mesh
 	clr1Str, clr2Str  string
 	x1, y1, x2, y2, r float64
 	objNum            int
+	r1                float64        // radius of start circle of radial gradient with stops
+	stops             []GradientStop // color stops, nil for two-color gradients
+	extend            [2]bool        // extend beyond start and end
+	gray              bool           // shade alpha values of stops in DeviceGray
+	mesh              []byte         // vertex or patch data of types 4 to 7
+	decode            [4]float64     // range of mesh coordinates in points
+	perRow            int            // vertices per row of type 5
+}
+
+// patternType holds a pattern used as a color
+type patternType struct {
+	shading int             // index into gradientList
+	tiling  int             // index into tilingList, 0 for shading patterns
+	matrix  TransformMatrix // pattern space to default page space
+	mask    int             // index into softMaskList of mask for transparent stops
+	objNum  int
+}
+
+// softMaskType holds a soft mask set with an ExtGState entry
+type softMaskType struct {
+	kind    string     // "Luminosity" or "Alpha"
+	bbox    [4]float64 // bounding box of mask form in points
+	content []byte     // content stream of mask form
+	objNum  int
+}
+
+// groupType holds a transparency group drawn as a form XObject
+type groupType struct {
+	isolated, knockout bool
+	gs                 int        // index into blendList of group alpha and blend mode
+	bbox               [4]float64 // bounding box of group form in points
+	content            []byte     // content stream of group form
+	objNum             int
 }
 
 const (
@@ -122,6 +164,7 @@ const (
 	colorModeRGB colorMode = iota
 	colorModeSpot
 	colorModeCMYK
+	colorModePattern
 )
 
 type colorType struct {
@@ -129,8 +172,10 @@ type colorType struct {
 	ir, ig, ib int
 	mode       colorMode
 	spotStr    string // name of current spot color
+	cmyk       cmykColorType
 	gray       bool
 	str        string
+	mask       int // soft mask of gradient with transparent stops
 }
 
 // SpotColorType specifies a named spot color value
@@ -176,6 +221,7 @@ type ImageInfoType struct {
 	f     string  // Image filter
 	dp    string  // DecodeParms
 	trns  []int   // Transparency mask
+	icc   []byte  // Embedded ICC color profile
 	scale float64 // Document scale factor
 	dpi   float64 // Dots-per-inch found from image file (png only)
 	i     string  // SHA-1 checksum of the above values.
@@ -190,6 +236,11 @@ func generateImageID(info *ImageInfoType) (string, error) {
 func (info *ImageInfoType) GobEncode() (buf []byte, err error) {
 	fields := []interface{}{info.data, info.smask, info.n, info.w, info.h, info.cs,
 		info.pal, info.bpc, info.f, info.dp, info.trns, info.scale, info.dpi}
+	if len(info.icc) > 0 {
+		// The profile is encoded only if present so that the identifiers of
+		// other images are unchanged
+		fields = append(fields, info.icc)
+	}
 	w := new(bytes.Buffer)
 	encoder := gob.NewEncoder(w)
 	for j := 0; j < len(fields) && err == nil; j++ {
@@ -211,6 +262,11 @@ func (info *ImageInfoType) GobDecode(buf []byte) (err error) {
 	for j := 0; j < len(fields) && err == nil; j++ {
 		err = decoder.Decode(fields[j])
 	}
+	if err == nil {
+		if iccErr := decoder.Decode(&info.icc); iccErr != nil && iccErr != io.EOF {
+			err = iccErr
+		}
+	}
 
 	info.i, err = generateImageID(info)
 	return
@@ -271,13 +327,17 @@ type fontFileType struct {
 
 type linkType struct {
 	x, y, wd, ht float64
-	link         int    // Auto-generated internal link ID or...
-	linkStr      string // ...application-provided external link string
+	link         int       // Auto-generated internal link ID or...
+	linkStr      string    // ...application-provided external link string
+	quad         []float64 // corners of a rotated or skewed link area, if any
 }
 
 type intLinkType struct {
-	page int
-	y    float64
+	page   int
+	y      float64
+	name   string // named destination, for links to names
+	file   string // target file, for remote and launch links
+	remote bool   // link opens file at named destination rather than launching it
 }
 
 // outlineType is used for a sidebar outline of bookmarks
@@ -286,6 +346,7 @@ type outlineType struct {
 	level, parent, first, last, next, prev int
 	y                                      float64
 	p                                      int
+	opt                                    *outlineOptType // nil for bookmarks without options
 }
 
 // InitType is used with NewCustom() to customize an Fpdf instance.
@@ -553,6 +614,7 @@ type Fpdf struct {
 	links            []intLinkType              // array of internal links
 	attachments      []Attachment               // slice of content to embed globally
 	pageAttachments  [][]annotationAttach       // 1-based array of annotation for file attachments (per page)
+	pageAnnots       [][]annotationType         // pageAnnots[page][annotation], page is 1-based
 	outlines         []outlineType              // array of outlines
 	outlineRoot      int                        // root of outlines
 	autoPageBreak    bool                       // automatic page breaking
@@ -586,15 +648,34 @@ type Fpdf struct {
 	blendMap         map[string]int             // map into blendList
 	blendMode        string                     // current blend mode
 	alpha            float64                    // current transpacency
+	overprint        overprintType              // current overprint settings
+	renderingIntent  string                     // current rendering intent
 	gradientList     []gradientType             // slice[idx] of gradient records
+	patternList      []patternType              // slice[idx] of pattern records, 1-based
+	patternMap       map[string]int             // map into patternList
+	tilingList       []tilingType               // slice[idx] of tiling pattern cells, 1-based
+	softMaskList     []softMaskType             // slice[idx] of soft masks, 0 removes mask
+	iccList          []iccType                  // slice[idx] of ICC color profiles, 1-based
+	iccMap           map[string]int             // map of profile checksum into iccList
+	iccDefaults      map[string]int             // default color space names mapped into iccList
+	outputIntent     outputIntentType           // output intent of document
+	softMask         int                        // index into softMaskList of mask set by ApplySoftMask()
+	softMaskStack    []int                      // masks saved by TransformBegin()
+	captureList      []*captureType             // stack of soft masks and transparency groups being drawn
+	pathMasked       bool                       // soft mask of fill color applied to path awaiting DrawPath()
+	groupList        []groupType                // slice[idx] of transparency groups, 1-based
 	clipNest         int                        // Number of active clipping contexts
 	transformNest    int                        // Number of active transformation contexts
+	ctm              TransformMatrix            // current transformation matrix, in points
+	ctmStack         []TransformMatrix          // matrices saved by TransformBegin()
 	err              error                      // Set if error occurs during life cycle of instance
 	protect          protectType                // document protection structure
 	layer            layerRecType               // manages optional layers in document
 	catalogSort      bool                       // sort resource catalogs in document
 	nJs              int                        // JavaScript object number
-	javascript       *string                    // JavaScript code to include in the PDF
+	javascripts      map[string]string          // named JavaScript code to include in the PDF
+	openAction       *Action                    // action performed when the document is opened
+	docActions       map[string]Action          // document actions keyed by trigger
 	colorFlag        bool                       // indicates whether fill and text colors are different
 	color            struct {
 		// Composite values of colors
@@ -602,6 +683,25 @@ type Fpdf struct {
 	}
 	spotColorMap           map[string]spotColorType // Map of named ink-based colors
 	userUnderlineThickness float64                  // A custom user underline thickness multiplier.
+	tabStops               []TabStop                // sorted tab stops used to expand tab characters
+	tabWrite               bool                     // tab stops are measured from left margin rather than cell edge
+	widowOrphan            int                      // minimum lines of a MultiCell paragraph kept together at a page break
+	keepWithNext           bool                     // keep next MultiCell block on the same page as the following block
+	widowTrigger           float64                  // page break trigger to restore after a widow-controlled break
+	pageTop                float64                  // vertical position following the header of the current page
+	txList                 []*txType                // stack of open layout transactions
+	tocEntries             []tocEntryType           // table of contents entries
+	tocPage                int                      // requested page number of table of contents, 0 if none
+	tocStyle               TOCStyle                 // table of contents appearance
+	tocFirst, tocCount     int                      // pages produced for table of contents before being moved
+	footerPage             int                      // page whose footer has yet to be output, 0 if none
+	pageInsertAt           int                      // position of page being added by InsertPageAt(), 0 if appending
+	pageLabels             map[int]pageLabelType    // page label ranges keyed by first page
+	aliasNbSectionPagesStr string                   // alias for number of pages in page label range
+	pageAttrs              map[int]pageAttrType     // used for page rotation, user unit, display duration and transition
+	viewerPrefs            *ViewerPrefs             // viewer preferences, nil if not set
+	namedDests             map[string]namedDestType // named destinations
+	nDests                 int                      // object number of named destination tree
 }
 
 type encType struct {
@@ -0,0 +1,220 @@
+package gofpdf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FitMode specifies how a destination page is displayed when a link or
+// bookmark is followed. It is used with AddNamedDestination().
+type FitMode string
+
+const (
+	// FitXYZ positions the point (x, y) at the upper left corner of the window
+	// and leaves the zoom factor unchanged
+	FitXYZ FitMode = "XYZ"
+	// FitPage fits the entire page in the window
+	FitPage FitMode = "Fit"
+	// FitWidth fits the width of the page in the window, with vertical
+	// position y at the top of the window
+	FitWidth FitMode = "FitH"
+	// FitHeight fits the height of the page in the window, with horizontal
+	// position x at the left edge of the window
+	FitHeight FitMode = "FitV"
+	// FitRect fits a rectangle of the page in the window; see
+	// AddNamedDestinationRect()
+	FitRect FitMode = "FitR"
+	// FitBounds fits the bounding box of the page content in the window
+	FitBounds FitMode = "FitB"
+	// FitBoundsWidth fits the width of the bounding box of the page content in
+	// the window, with vertical position y at the top of the window
+	FitBoundsWidth FitMode = "FitBH"
+	// FitBoundsHeight fits the height of the bounding box of the page content
+	// in the window, with horizontal position x at the left edge of the window
+	FitBoundsHeight FitMode = "FitBV"
+)
+
+type namedDestType struct {
+	page         int
+	x, y, wd, ht float64
+	fit          FitMode
+	zoom         float64
+}
+
+// AddNamedDestination defines a destination that links can refer to by name,
+// both within this document (see AddNamedLink()) and from other documents
+// (see AddRemoteLink()). page is the one-based page number, or -1 for the
+// current page. (x, y) is the position on the page in the units established
+// in New(); a value of -1 for y indicates the current vertical position. fit
+// determines how the page is displayed; which of x and y it uses is described
+// with the FitMode constants. Use AddNamedDestinationRect() for FitRect.
+// Defining a name again replaces the earlier destination.
+//
+// The AddNamedDestination() example demonstrates this method.
+func (f *Fpdf) AddNamedDestination(name string, page int, x, y float64, fit FitMode) {
+	if f.err != nil {
+		return
+	}
+	switch fit {
+	case FitXYZ, FitPage, FitWidth, FitHeight, FitBounds, FitBoundsWidth, FitBoundsHeight:
+	case FitRect:
+		f.err = fmt.Errorf("destination \"%s\" requires a rectangle; use AddNamedDestinationRect()", name)
+		return
+	default:
+		f.err = fmt.Errorf("invalid fit mode \"%s\"", fit)
+		return
+	}
+	f.namedDestAdd(name, page, namedDestType{x: x, y: y, fit: fit})
+}
+
+// AddNamedDestinationRect defines a named destination that displays the
+// rectangle of page specified by the upper left corner (x, y), width wd and
+// height ht, magnified to fit the window. See AddNamedDestination() for
+// details.
+//
+// The AddNamedDestination() example demonstrates this method.
+func (f *Fpdf) AddNamedDestinationRect(name string, page int, x, y, wd, ht float64) {
+	if f.err != nil {
+		return
+	}
+	f.namedDestAdd(name, page, namedDestType{x: x, y: y, wd: wd, ht: ht, fit: FitRect})
+}
+
+// namedDestAdd validates page and records the destination
+func (f *Fpdf) namedDestAdd(name string, page int, dest namedDestType) {
+	if name == "" {
+		f.err = fmt.Errorf("named destination requires a name")
+		return
+	}
+	if page == -1 {
+		page = f.page
+	}
+	if page < 1 || page > f.PageCount() {
+		f.err = fmt.Errorf("invalid page %d for destination \"%s\"", page, name)
+		return
+	}
+	if dest.y == -1 {
+		dest.y = f.y
+	}
+	dest.page = page
+	if f.namedDests == nil {
+		f.namedDests = make(map[string]namedDestType)
+	}
+	f.namedDests[name] = dest
+}
+
+// AddNamedLink creates a new internal link that targets the destination
+// defined with AddNamedDestination() under name. The returned identifier can
+// be passed to Cell(), Write(), Image() or Link() like one returned by
+// AddLink().
+//
+// The AddNamedDestination() example demonstrates this method.
+func (f *Fpdf) AddNamedLink(name string) int {
+	f.links = append(f.links, intLinkType{name: name})
+	return len(f.links) - 1
+}
+
+// AddRemoteLink creates a new link that opens the PDF document fileStr and
+// displays its destination named name. If name is empty, the first page of
+// the document is displayed. The returned identifier can be passed to Cell(),
+// Write(), Image() or Link() like one returned by AddLink().
+//
+// The AddNamedDestination() example demonstrates this method.
+func (f *Fpdf) AddRemoteLink(fileStr, name string) int {
+	f.links = append(f.links, intLinkType{file: fileStr, name: name, remote: true})
+	return len(f.links) - 1
+}
+
+// AddLaunchLink creates a new link that opens or executes fileStr with the
+// application associated with it. Many viewers ask the user for confirmation
+// before doing so, or refuse. The returned identifier can be passed to Cell(),
+// Write(), Image() or Link() like one returned by AddLink().
+//
+// The AddNamedDestination() example demonstrates this method.
+func (f *Fpdf) AddLaunchLink(fileStr string) int {
+	f.links = append(f.links, intLinkType{file: fileStr})
+	return len(f.links) - 1
+}
+
+// pageHeightPt returns the height in points of page n
+func (f *Fpdf) pageHeightPt(n int) float64 {
+	if sz, ok := f.pageSizes[n]; ok {
+		return sz.Ht
+	}
+	if f.defOrientation == "P" {
+		return f.defPageSize.Ht * f.k
+	}
+	return f.defPageSize.Wd * f.k
+}
+
+// pageWidthPt returns the width in points of page n
+func (f *Fpdf) pageWidthPt(n int) float64 {
+	if sz, ok := f.pageSizes[n]; ok {
+		return sz.Wd
+	}
+	if f.defOrientation == "P" {
+		return f.defPageSize.Wd * f.k
+	}
+	return f.defPageSize.Ht * f.k
+}
+
+// destArray returns the PDF destination array for dest
+func (f *Fpdf) destArray(dest namedDestType) string {
+	h := f.pageHeightPt(dest.page)
+	obj := 1 + 2*dest.page
+	left, top := dest.x*f.k, h-dest.y*f.k
+	switch dest.fit {
+	case FitPage, FitBounds:
+		return sprintf("[%d 0 R /%s]", obj, dest.fit)
+	case FitWidth, FitBoundsWidth:
+		return sprintf("[%d 0 R /%s %.2f]", obj, dest.fit, top)
+	case FitHeight, FitBoundsHeight:
+		return sprintf("[%d 0 R /%s %.2f]", obj, dest.fit, left)
+	case FitRect:
+		return sprintf("[%d 0 R /FitR %.2f %.2f %.2f %.2f]", obj, left, h-(dest.y+dest.ht)*f.k,
+			(dest.x+dest.wd)*f.k, top)
+	}
+	if dest.zoom > 0 {
+		return sprintf("[%d 0 R /XYZ %.2f %.2f %.2f]", obj, left, top, dest.zoom)
+	}
+	return sprintf("[%d 0 R /XYZ %.2f %.2f null]", obj, left, top)
+}
+
+// linkAction returns the annotation entry for a link created with
+// AddNamedLink(), AddRemoteLink() or AddLaunchLink(). ok is false for links
+// to a page of this document.
+func (f *Fpdf) linkAction(l intLinkType) (s string, ok bool) {
+	switch {
+	case l.remote:
+		dest := "[0 /Fit]"
+		if l.name != "" {
+			dest = f.textstring(l.name)
+		}
+		return sprintf("/A <</S /GoToR /F %s /D %s>>", f.textstring(l.file), dest), true
+	case l.file != "":
+		return sprintf("/A <</S /Launch /F %s>>", f.textstring(l.file)), true
+	case l.name != "":
+		return sprintf("/Dest %s", f.textstring(l.name)), true
+	}
+	return
+}
+
+// putNamedDests writes the name tree of named destinations
+func (f *Fpdf) putNamedDests() {
+	if len(f.namedDests) == 0 {
+		return
+	}
+	names := make([]string, 0, len(f.namedDests))
+	for name := range f.namedDests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	f.newobj()
+	f.nDests = f.n
+	f.out("<</Names [")
+	for _, name := range names {
+		f.outf("%s %s", f.textstring(name), f.destArray(f.namedDests[name]))
+	}
+	f.out("]>>")
+	f.out("endobj")
+}
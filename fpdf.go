@@ -59,6 +59,7 @@ func (b *fmtBuffer) printf(fmtStr string, args ...interface{}) {
 
 func fpdfNew(orientationStr, unitStr, sizeStr, fontDirStr string, size SizeType) (f *Fpdf) {
 	f = new(Fpdf)
+	f.ctm = identityMatrix
 	if orientationStr == "" {
 		orientationStr = "p"
 	} else {
@@ -97,6 +98,7 @@ func fpdfNew(orientationStr, unitStr, sizeStr, fontDirStr string, size SizeType)
 	f.links = append(f.links, intLinkType{}) // links[0] is unused (1-based)
 	f.pageAttachments = make([][]annotationAttach, 0, 8)
 	f.pageAttachments = append(f.pageAttachments, []annotationAttach{}) //
+	f.pageAnnots = make([][]annotationType, 1, 8)
 	f.aliasMap = make(map[string]string)
 	f.inHeader = false
 	f.inFooter = false
@@ -663,6 +665,10 @@ func (f *Fpdf) Close() {
 			f.err = fmt.Errorf("clip procedure must be explicitly ended")
 		} else if f.transformNest > 0 {
 			f.err = fmt.Errorf("transformation procedure must be explicitly ended")
+		} else if len(f.txList) > 0 {
+			f.err = fmt.Errorf("layout transaction must be committed or rolled back")
+		} else if len(f.captureList) > 0 {
+			f.err = fmt.Errorf("soft mask or transparency group must be ended")
 		}
 	}
 	if f.err != nil {
@@ -677,17 +683,31 @@ func (f *Fpdf) Close() {
 			return
 		}
 	}
+	// Table of contents
+	f.tocRender()
+	if f.err != nil {
+		return
+	}
 	// Page footer
-	f.inFooter = true
-	if f.footerFnc != nil {
-		f.footerFnc()
-	} else if f.footerFncLpi != nil {
-		f.footerFncLpi(true)
+	if f.footerPage > 0 {
+		f.page = f.footerPage
+		f.inFooter = true
+		if f.footerFnc != nil {
+			f.footerFnc()
+		} else if f.footerFncLpi != nil {
+			f.footerFncLpi(true)
+		}
+		f.inFooter = false
 	}
-	f.inFooter = false
 
 	// Close page
 	f.endpage()
+	f.tocPlace()
+	f.page = len(f.pages) - 1
+	f.actionsCheck()
+	if f.err != nil {
+		return
+	}
 	// Close document
 	f.enddoc()
 	return
@@ -720,6 +740,10 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) {
 	if f.err != nil {
 		return
 	}
+	if len(f.captureList) > 0 {
+		f.err = fmt.Errorf("a page cannot be added while a soft mask or transparency group is drawn")
+		return
+	}
 	if f.page != len(f.pages)-1 {
 		f.page = len(f.pages) - 1
 	}
@@ -742,18 +766,25 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) {
 	cf := f.colorFlag
 
 	if f.page > 0 {
-		f.inFooter = true
-		// Page footer avoid double call on footer.
-		if f.footerFnc != nil {
-			f.footerFnc()
-
-		} else if f.footerFncLpi != nil {
-			f.footerFncLpi(false) // not last page.
+		// The footer of the last page is output unless a page is being inserted
+		// ahead of it
+		if f.footerPage > 0 && f.pageInsertAt == 0 {
+			f.page = f.footerPage
+			f.inFooter = true
+			// Page footer avoid double call on footer.
+			if f.footerFnc != nil {
+				f.footerFnc()
+
+			} else if f.footerFncLpi != nil {
+				f.footerFncLpi(false) // not last page.
+			}
+			f.inFooter = false
 		}
-		f.inFooter = false
 		// Close page
 		f.endpage()
 	}
+	// The graphics state, including the soft mask, is reset on a new page
+	f.softMask = 0
 	// Start new page
 	f.beginpage(orientationStr, size)
 	// 	Set line cap style to current value
@@ -786,6 +817,23 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) {
 	}
 	f.color.text = tc
 	f.colorFlag = cf
+	// Set overprint and rendering intent
+	if f.overprint.set || f.renderingIntent != "" {
+		f.graphicsStateOut()
+	}
+	if f.pageInsertAt > 0 {
+		// An inserted page is not the last page, so its footer is output now
+		f.pageInsertAt = 0
+		f.inFooter = true
+		if f.footerFnc != nil {
+			f.footerFnc()
+		} else if f.footerFncLpi != nil {
+			f.footerFncLpi(false)
+		}
+		f.inFooter = false
+		f.x = f.lMargin
+		f.y = f.tMargin
+	}
 	// 	Page header
 	if f.headerFnc != nil {
 		f.inHeader = true
@@ -795,6 +843,7 @@ func (f *Fpdf) AddPageFormat(orientationStr string, size SizeType) {
 			f.SetHomeXY()
 		}
 	}
+	f.pageTop = f.y
 	// 	Restore line width
 	if f.lineWidth != lw {
 		f.lineWidth = lw
@@ -1119,7 +1168,10 @@ func fillDrawOp(styleStr string) (opStr string) {
 // draw color and line width centered on the rectangle's perimeter. Filling
 // uses the current fill color.
 func (f *Fpdf) Rect(x, y, w, h float64, styleStr string) {
-	f.outf("%.2f %.2f %.2f %.2f re %s", x*f.k, (f.h-y)*f.k, w*f.k, -h*f.k, fillDrawOp(styleStr))
+	opStr := fillDrawOp(styleStr)
+	masked := f.fillMaskBegin(opStr)
+	f.outf("%.2f %.2f %.2f %.2f re %s", x*f.k, (f.h-y)*f.k, w*f.k, -h*f.k, opStr)
+	f.fillMaskEnd(masked)
 }
 
 // RoundedRect outputs a rectangle of width w and height h with the upper left
@@ -1155,8 +1207,11 @@ func (f *Fpdf) RoundedRect(x, y, w, h, r float64, corners string, stylestr strin
 // RoundedRect() for more details. This method is demonstrated in the
 // RoundedRect() example.
 func (f *Fpdf) RoundedRectExt(x, y, w, h, rTL, rTR, rBR, rBL float64, stylestr string) {
+	opStr := fillDrawOp(stylestr)
+	masked := f.fillMaskBegin(opStr)
 	f.roundedRectPath(x, y, w, h, rTL, rTR, rBR, rBL)
-	f.out(fillDrawOp(stylestr))
+	f.out(opStr)
+	f.fillMaskEnd(masked)
 }
 
 // Circle draws a circle centered on point (x, y) with radius r.
@@ -1196,6 +1251,7 @@ func (f *Fpdf) Ellipse(x, y, rx, ry, degRotate float64, styleStr string) {
 // Filling uses the current fill color.
 func (f *Fpdf) Polygon(points []PointType, styleStr string) {
 	if len(points) > 2 {
+		f.pathBegin()
 		for j, pt := range points {
 			if j == 0 {
 				f.point(pt.X, pt.Y)
@@ -1226,6 +1282,7 @@ func (f *Fpdf) Beziergon(points []PointType, styleStr string) {
 	if len(points) < 4 {
 		return
 	}
+	f.pathBegin()
 	f.point(points[0].XY())
 
 	points = points[1:]
@@ -1266,9 +1323,11 @@ func (f *Fpdf) curve(cx0, cy0, cx1, cy1, x, y float64) {
 //
 // The Circle() example demonstrates this method.
 func (f *Fpdf) Curve(x0, y0, cx, cy, x1, y1 float64, styleStr string) {
+	opStr := fillDrawOp(styleStr)
+	masked := f.fillMaskBegin(opStr)
 	f.point(x0, y0)
-	f.outf("%.5f %.5f %.5f %.5f v %s", cx*f.k, (f.h-cy)*f.k, x1*f.k, (f.h-y1)*f.k,
-		fillDrawOp(styleStr))
+	f.outf("%.5f %.5f %.5f %.5f v %s", cx*f.k, (f.h-cy)*f.k, x1*f.k, (f.h-y1)*f.k, opStr)
+	f.fillMaskEnd(masked)
 }
 
 // CurveCubic draws a single-segment cubic Bézier curve. This routine performs
@@ -1298,9 +1357,12 @@ func (f *Fpdf) CurveCubic(x0, y0, cx0, cy0, x1, y1, cx1, cy1 float64, styleStr s
 //
 // The Circle() example demonstrates this method.
 func (f *Fpdf) CurveBezierCubic(x0, y0, cx0, cy0, cx1, cy1, x1, y1 float64, styleStr string) {
+	opStr := fillDrawOp(styleStr)
+	masked := f.fillMaskBegin(opStr)
 	f.point(x0, y0)
 	f.outf("%.5f %.5f %.5f %.5f %.5f %.5f c %s", cx0*f.k, (f.h-cy0)*f.k,
-		cx1*f.k, (f.h-cy1)*f.k, x1*f.k, (f.h-y1)*f.k, fillDrawOp(styleStr))
+		cx1*f.k, (f.h-cy1)*f.k, x1*f.k, (f.h-y1)*f.k, opStr)
+	f.fillMaskEnd(masked)
 }
 
 // Arc draws an elliptical arc centered at point (x, y). rx and ry specify its
@@ -1345,6 +1407,20 @@ func (f *Fpdf) SetAlpha(alpha float64, blendModeStr string) {
 	if f.err != nil {
 		return
 	}
+	pos := f.blendAdd(alpha, blendModeStr)
+	if pos == 0 {
+		return
+	}
+	f.alpha = alpha
+	f.blendMode = blendModeStr
+	f.outf("/GS%d gs", pos)
+}
+
+// blendAdd registers the ExtGState for alpha and blendModeStr, which are
+// validated as with SetAlpha(), combined with the current overprint settings
+// and rendering intent, and returns its index in the blend list, or 0 if an
+// error occurred
+func (f *Fpdf) blendAdd(alpha float64, blendModeStr string) int {
 	var bl blendModeType
 	switch blendModeStr {
 	case "Normal", "Multiply", "Screen", "Overlay",
@@ -1355,23 +1431,92 @@ func (f *Fpdf) SetAlpha(alpha float64, blendModeStr string) {
 		bl.modeStr = "Normal"
 	default:
 		f.err = fmt.Errorf("unrecognized blend mode \"%s\"", blendModeStr)
-		return
+		return 0
 	}
 	if alpha < 0.0 || alpha > 1.0 {
 		f.err = fmt.Errorf("alpha value (0.0 - 1.0) is out of range: %.3f", alpha)
-		return
+		return 0
 	}
-	f.alpha = alpha
-	f.blendMode = blendModeStr
 	alphaStr := sprintf("%.3f", alpha)
-	keyStr := sprintf("%s %s", alphaStr, blendModeStr)
+	// Overprint and rendering intent share the entry so that they remain in
+	// effect when the alpha is changed
+	keyStr := sprintf("%s %s %v %s", alphaStr, bl.modeStr, f.overprint, f.renderingIntent)
 	pos, ok := f.blendMap[keyStr]
 	if !ok {
 		pos = len(f.blendList) // at least 1
-		f.blendList = append(f.blendList, blendModeType{alphaStr, alphaStr, blendModeStr, 0})
+		f.blendList = append(f.blendList, blendModeType{strokeStr: alphaStr, fillStr: alphaStr,
+			modeStr: bl.modeStr, overprint: f.overprint, intentStr: f.renderingIntent})
 		f.blendMap[keyStr] = pos
 	}
-	f.outf("/GS%d gs", pos)
+	return pos
+}
+
+// SetOverprint specifies whether colors painted by strokes and fills overprint,
+// that is, whether they are printed on top of the inks of the content below
+// rather than knocking them out. This is typically used for black text and for
+// traps between spot colors. stroke applies to lines and text outlines and
+// fill to filled shapes, text and images. mode is the overprint mode: with 0,
+// each component of a CMYK color knocks out the corresponding ink; with 1,
+// components of zero leave the inks below unchanged, as is usual for
+// overprinting CMYK colors. Values other than 0 and 1 result in an error.
+//
+// Overprinting is only visible on output devices, and in viewers, that
+// simulate separations. The settings are combined with those of SetAlpha()
+// and SetRenderingIntent(). This method can be called before the first page is
+// created and the settings are retained from page to page.
+//
+// The SetOverprint() example demonstrates this method.
+func (f *Fpdf) SetOverprint(stroke, fill bool, mode int) {
+	if f.err != nil {
+		return
+	}
+	if mode != 0 && mode != 1 {
+		f.err = fmt.Errorf("overprint mode must be 0 or 1, not %d", mode)
+		return
+	}
+	f.overprint = overprintType{set: true, stroke: stroke, fill: fill, mode: mode}
+	f.graphicsStateOut()
+}
+
+// GetOverprint returns the overprint settings set with SetOverprint().
+func (f *Fpdf) GetOverprint() (stroke, fill bool, mode int) {
+	return f.overprint.stroke, f.overprint.fill, f.overprint.mode
+}
+
+// SetRenderingIntent specifies how colors are mapped to the gamut of the
+// output device. intentStr is one of "AbsoluteColorimetric",
+// "RelativeColorimetric", "Saturation" or "Perceptual". The intent is
+// combined with the settings of SetAlpha() and SetOverprint(). Like
+// SetOverprint(), this method can be called before the first page is created.
+//
+// The SetOverprint() example demonstrates this method.
+func (f *Fpdf) SetRenderingIntent(intentStr string) {
+	if f.err != nil {
+		return
+	}
+	switch intentStr {
+	case "AbsoluteColorimetric", "RelativeColorimetric", "Saturation", "Perceptual":
+	default:
+		f.err = fmt.Errorf("unrecognized rendering intent \"%s\"", intentStr)
+		return
+	}
+	f.renderingIntent = intentStr
+	f.graphicsStateOut()
+}
+
+// GetRenderingIntent returns the rendering intent set with
+// SetRenderingIntent(), or an empty string if none has been set.
+func (f *Fpdf) GetRenderingIntent() string {
+	return f.renderingIntent
+}
+
+// graphicsStateOut selects the ExtGState of the current alpha, blend mode,
+// overprint settings and rendering intent
+func (f *Fpdf) graphicsStateOut() {
+	pos := f.blendAdd(f.alpha, f.blendMode)
+	if pos > 0 && f.page > 0 {
+		f.outf("/GS%d gs", pos)
+	}
 }
 
 func (f *Fpdf) gradientClipStart(x, y, w, h float64) {
@@ -1390,8 +1535,8 @@ func (f *Fpdf) gradient(tp, r1, g1, b1, r2, g2, b2 int, x1, y1, x2, y2, r float6
 	pos := len(f.gradientList)
 	clr1 := rgbColorValue(r1, g1, b1, "", "")
 	clr2 := rgbColorValue(r2, g2, b2, "", "")
-	f.gradientList = append(f.gradientList, gradientType{tp, clr1.str, clr2.str,
-		x1, y1, x2, y2, r, 0})
+	f.gradientList = append(f.gradientList, gradientType{tp: tp, clr1Str: clr1.str, clr2Str: clr2.str,
+		x1: x1, y1: y1, x2: x2, y2: y2, r: r})
 	f.outf("/Sh%d sh", pos)
 }
 
@@ -2156,24 +2301,80 @@ func (f *Fpdf) SetLink(link int, y float64, page int) {
 	if page == -1 {
 		page = f.page
 	}
-	f.links[link] = intLinkType{page, y}
+	f.links[link] = intLinkType{page: page, y: y}
 }
 
-// newLink adds a new clickable link on current page
+// newLink adds a new clickable link on current page. The link area is mapped
+// through the current transformation matrix. Links within soft masks are
+// discarded since the content of a mask is not visible.
 func (f *Fpdf) newLink(x, y, w, h float64, link int, linkStr string) {
+	for _, c := range f.captureList {
+		if c.kind != "Group" {
+			return
+		}
+	}
 	// linkList, ok := f.pageLinks[f.page]
 	// if !ok {
 	// linkList = make([]linkType, 0, 8)
 	// f.pageLinks[f.page] = linkList
 	// }
 	f.pageLinks[f.page] = append(f.pageLinks[f.page],
-		linkType{x * f.k, f.hPt - y*f.k, w * f.k, h * f.k, link, linkStr})
+		f.linkTransform(linkType{x * f.k, f.hPt - y*f.k, w * f.k, h * f.k, link, linkStr, nil}, f.ctm))
+}
+
+// linkTransform returns l with its area mapped through tm. The link
+// rectangle becomes the bounding box of the transformed area; if the area is
+// rotated or skewed, its corners are retained for the /QuadPoints entry of
+// the link annotation.
+func (f *Fpdf) linkTransform(l linkType, tm TransformMatrix) linkType {
+	if tm == identityMatrix {
+		return l
+	}
+	quad := l.quad
+	if quad == nil {
+		// Lower left, lower right, upper right and upper left corners
+		quad = []float64{l.x, l.y - l.ht, l.x + l.wd, l.y - l.ht, l.x + l.wd, l.y, l.x, l.y}
+	}
+	pts := make([]float64, len(quad))
+	for j := 0; j < len(quad); j += 2 {
+		pts[j], pts[j+1] = tm.apply(quad[j], quad[j+1])
+	}
+	x0, y0, x1, y1 := pts[0], pts[1], pts[0], pts[1]
+	for j := 2; j < len(pts); j += 2 {
+		x0, x1 = math.Min(x0, pts[j]), math.Max(x1, pts[j])
+		y0, y1 = math.Min(y0, pts[j+1]), math.Max(y1, pts[j+1])
+	}
+	l.x, l.y, l.wd, l.ht = x0, y1, x1-x0, y1-y0
+	l.quad = nil
+	if l.quadPointsNeeded(pts) {
+		l.quad = pts
+		if f.pdfVersion < "1.6" {
+			f.pdfVersion = "1.6"
+		}
+	}
+	return l
+}
+
+// quadPointsNeeded returns true if the corners in pts do not coincide with
+// the corners of the link rectangle, that is, if the area is not an upright
+// rectangle
+func (l linkType) quadPointsNeeded(pts []float64) bool {
+	const eps = 0.005
+	for j := 0; j < len(pts); j += 2 {
+		onX := math.Abs(pts[j]-l.x) < eps || math.Abs(pts[j]-(l.x+l.wd)) < eps
+		onY := math.Abs(pts[j+1]-l.y) < eps || math.Abs(pts[j+1]-(l.y-l.ht)) < eps
+		if !onX || !onY {
+			return true
+		}
+	}
+	return false
 }
 
 // Link puts a link on a rectangular area of the page. Text or image links are
 // generally put via Cell(), Write() or Image(), but this method can be useful
 // for instance to define a clickable area inside an image. link is the value
-// returned by AddLink().
+// returned by AddLink(). Within a transformation context, the area follows
+// the transformation; see TransformBegin().
 func (f *Fpdf) Link(x, y, w, h float64, link int) {
 	f.newLink(x, y, w, h, link, "")
 }
@@ -2190,7 +2391,8 @@ func (f *Fpdf) LinkString(x, y, w, h float64, linkStr string) {
 // is the title of the bookmark. level specifies the level of the bookmark in
 // the outline; 0 is the top level, 1 is just below, and so on. y specifies the
 // vertical position of the bookmark destination in the current page; -1
-// indicates the current position.
+// indicates the current position. See BookmarkWithOptions() for bookmarks
+// with a particular appearance or destination.
 func (f *Fpdf) Bookmark(txtStr string, level int, y float64) {
 	if y == -1 {
 		y = f.y
@@ -2323,7 +2525,7 @@ func (f *Fpdf) CellFormat(w, h float64, txtStr, borderStr string, ln int,
 
 	borderStr = strings.ToUpper(borderStr)
 	k := f.k
-	if f.y+h > f.pageBreakTrigger && !f.inHeader && !f.inFooter && f.acceptPageBreak() {
+	if f.y+h > f.pageBreakTrigger && !f.inHeader && !f.inFooter && f.pageBreakAccepted() {
 		// Automatic page break
 		x := f.x
 		ws := f.ws
@@ -2361,7 +2563,13 @@ func (f *Fpdf) CellFormat(w, h float64, txtStr, borderStr string, ln int,
 			op = "S"
 		}
 		/// dbg("(CellFormat) f.x %.2f f.k %.2f", f.x, f.k)
-		s.printf("%.2f %.2f %.2f %.2f re %s ", f.x*k, (f.h-f.y)*k, w*k, -h*k, op)
+		if fill && f.color.fill.mask > 0 {
+			// The soft mask of a fill gradient applies only to the background
+			s.printf("q /SM%d gs %.2f %.2f %.2f %.2f re %s Q ", f.color.fill.mask,
+				f.x*k, (f.h-f.y)*k, w*k, -h*k, op)
+		} else {
+			s.printf("%.2f %.2f %.2f %.2f re %s ", f.x*k, (f.h-f.y)*k, w*k, -h*k, op)
+		}
 	}
 	if len(borderStr) > 0 && borderStr != "1" {
 		// fmt.Printf("border is '%s', no fill\n", borderStr)
@@ -2386,12 +2594,27 @@ func (f *Fpdf) CellFormat(w, h float64, txtStr, borderStr string, ln int,
 	}
 	if len(txtStr) > 0 {
 		var dx, dy float64
+		var pieces []tabPieceType
+		txtWd := f.GetStringWidth(txtStr)
+		if f.tabActive(txtStr) {
+			// Tab stops determine the horizontal placement of text
+			origin, x := f.x, f.x+f.cMargin
+			if f.isRTL {
+				// Stops are measured leftward from the right edge of the cell
+				origin, x = f.x+w, f.x+w-f.cMargin
+			}
+			if f.tabWrite {
+				origin = f.lMargin
+			}
+			pieces, txtWd = f.tabLayout(txtStr, x, origin)
+			alignStr = strings.NewReplacer("L", "", "C", "", "R", "", "J", "").Replace(alignStr)
+		}
 		// Horizontal alignment
 		switch {
 		case strings.Contains(alignStr, "R"):
-			dx = w - f.cMargin - f.GetStringWidth(txtStr)
+			dx = w - f.cMargin - txtWd
 		case strings.Contains(alignStr, "C"):
-			dx = (w - f.GetStringWidth(txtStr)) / 2
+			dx = (w - txtWd) / 2
 		default:
 			dx = f.cMargin
 		}
@@ -2418,8 +2641,44 @@ func (f *Fpdf) CellFormat(w, h float64, txtStr, borderStr string, ln int,
 		if f.colorFlag {
 			s.printf("q %s ", f.color.text.str)
 		}
-		//If multibyte, Tw has no effect - do word spacing using an adjustment before each space
-		if (f.ws != 0 || alignStr == "J") && f.isCurrentUTF8 { // && f.ws != 0
+		if len(pieces) > 0 {
+			td := (f.h - (f.y + dy + .5*h + .3*f.fontSize)) * k
+			if f.ws != 0 {
+				// Fragments are positioned by the tab stops, so the word
+				// spacing of justified text does not apply to them
+				s.printf("0 Tw ")
+			}
+			for j, pc := range pieces {
+				var txt2 string
+				if f.isCurrentUTF8 {
+					if f.isRTL {
+						pc.txt = reverseText(pc.txt)
+					}
+					txt2 = f.escape(utf8toutf16(pc.txt, false))
+					for _, uni := range []rune(pc.txt) {
+						f.currentFont.usedRunes[int(uni)] = int(uni)
+					}
+				} else {
+					txt2 = f.escape(pc.txt)
+				}
+				if j > 0 {
+					s.printf(" ")
+				}
+				s.printf("BT %.2f %.2f Td (%s)Tj ET", pc.x*k, td, txt2)
+				if !pc.leader {
+					if f.underline {
+						s.printf(" %s", f.dounderline(pc.x, f.y+dy+.5*h+.3*f.fontSize, pc.txt))
+					}
+					if f.strikeout {
+						s.printf(" %s", f.dostrikeout(pc.x, f.y+dy+.5*h+.3*f.fontSize, pc.txt))
+					}
+				}
+			}
+			if f.ws != 0 {
+				s.printf(" %.3f Tw", f.ws*k)
+			}
+		} else if (f.ws != 0 || alignStr == "J") && f.isCurrentUTF8 {
+			//If multibyte, Tw has no effect - do word spacing using an adjustment before each space
 			if f.isRTL {
 				txtStr = reverseText(txtStr)
 			}
@@ -2464,17 +2723,17 @@ func (f *Fpdf) CellFormat(w, h float64, txtStr, borderStr string, ln int,
 			//BT %.2F %.2F Td (%s) Tj ET',(f.x+dx)*k,(f.h-(f.y+.5*h+.3*f.FontSize))*k,txt2);
 		}
 
-		if f.underline {
+		if f.underline && len(pieces) == 0 {
 			s.printf(" %s", f.dounderline(f.x+dx, f.y+dy+.5*h+.3*f.fontSize, txtStr))
 		}
-		if f.strikeout {
+		if f.strikeout && len(pieces) == 0 {
 			s.printf(" %s", f.dostrikeout(f.x+dx, f.y+dy+.5*h+.3*f.fontSize, txtStr))
 		}
 		if f.colorFlag {
 			s.printf(" Q")
 		}
 		if link > 0 || len(linkStr) > 0 {
-			f.newLink(f.x+dx, f.y+dy+.5*h-.5*f.fontSize, f.GetStringWidth(txtStr), f.fontSize, link, linkStr)
+			f.newLink(f.x+dx, f.y+dy+.5*h-.5*f.fontSize, txtWd, f.fontSize, link, linkStr)
 		}
 	}
 	str := s.String()
@@ -2607,6 +2866,8 @@ func (f *Fpdf) MultiCell(w, h float64, txtStr, borderStr, alignStr string, fill
 	if w == 0 {
 		w = f.w - f.rMargin - f.x
 	}
+	f.multiCellKeep(w, h, txtStr)
+	defer f.widowRestore()
 	wmax := int(math.Ceil((w - 2*f.cMargin) * 1000 / f.fontSize))
 	s := strings.Replace(txtStr, "\r", "", -1)
 	srune := []rune(s)
@@ -2713,7 +2974,15 @@ func (f *Fpdf) MultiCell(w, h float64, txtStr, borderStr, alignStr string, fill
 			f.err = fmt.Errorf("character outside the supported range: %s", string(c))
 			return
 		}
-		if cw[int(c)] == 0 { //Marker width 0 used for missing symbols
+		if c == '\t' && len(f.tabStops) > 0 {
+			var rest string
+			if f.isCurrentUTF8 {
+				rest = string(srune[i+1:])
+			} else {
+				rest = s[i+1:]
+			}
+			l += int(math.Ceil(f.tabAdvance(f.cMargin+float64(l)*f.fontSize/1000, tabSegment(rest))))
+		} else if cw[int(c)] == 0 { //Marker width 0 used for missing symbols
 			l += f.currentFont.Desc.MissingWidth
 		} else if cw[int(c)] != 65535 { //Marker width 65535 used for zero width symbols
 			l += cw[int(c)]
@@ -2801,6 +3070,16 @@ func (f *Fpdf) write(h float64, txtStr string, link int, linkStr string) {
 	} else {
 		nb = len(s)
 	}
+	if f.tabActive(s) {
+		if f.isRTL {
+			f.err = fmt.Errorf("tab stops are not supported by Write() in right-to-left mode")
+			return
+		}
+		// Tab stops are measured from the left margin rather than the
+		// beginning of each written fragment
+		f.tabWrite = true
+		defer func() { f.tabWrite = false }()
+	}
 	sep := -1
 	i := 0
 	j := 0
@@ -2836,7 +3115,17 @@ func (f *Fpdf) write(h float64, txtStr string, link int, linkStr string) {
 		if c == ' ' {
 			sep = i
 		}
-		l += float64(cw[int(c)])
+		if c == '\t' && len(f.tabStops) > 0 {
+			var rest string
+			if f.isCurrentUTF8 {
+				rest = string([]rune(s)[i+1:])
+			} else {
+				rest = s[i+1:]
+			}
+			l += f.tabAdvance(f.x+f.cMargin-f.lMargin+l*f.fontSize/1000, tabSegment(rest))
+		} else {
+			l += float64(cw[int(c)])
+		}
 		if l > wmax {
 			// Automatic line break
 			if sep == -1 {
@@ -3510,7 +3799,7 @@ func (f *Fpdf) beginpage(orientationStr string, size SizeType) {
 	if f.err != nil {
 		return
 	}
-	f.page++
+	f.page = len(f.pages)
 	// add the default page boxes, if any exist, to the page
 	f.pageBoxes[f.page] = make(map[string]PageBox)
 	for box, pb := range f.defPageBoxes {
@@ -3519,6 +3808,8 @@ func (f *Fpdf) beginpage(orientationStr string, size SizeType) {
 	f.pages = append(f.pages, bytes.NewBufferString(""))
 	f.pageLinks = append(f.pageLinks, make([]linkType, 0, 0))
 	f.pageAttachments = append(f.pageAttachments, []annotationAttach{})
+	f.pageAnnots = append(f.pageAnnots, nil)
+	f.ctm = identityMatrix
 	f.state = 2
 	f.x = f.lMargin
 	f.y = f.tMargin
@@ -3547,6 +3838,11 @@ func (f *Fpdf) beginpage(orientationStr string, size SizeType) {
 	if orientationStr != f.defOrientation || size.Wd != f.defPageSize.Wd || size.Ht != f.defPageSize.Ht {
 		f.pageSizes[f.page] = SizeType{f.wPt, f.hPt}
 	}
+	if f.pageInsertAt > 0 {
+		f.movePage(f.page, f.pageInsertAt)
+	} else {
+		f.footerPage = f.page
+	}
 	return
 }
 
@@ -3667,6 +3963,7 @@ func (f *Fpdf) parsejpg(r io.Reader) (info *ImageInfoType) {
 	}
 	info.w = float64(config.Width)
 	info.h = float64(config.Height)
+	info.icc = jpegICCProfile(info.data)
 	info.f = "DCTDecode"
 	info.bpc = 8
 	switch config.ColorModel {
@@ -3837,9 +4134,10 @@ func (f *Fpdf) SetModificationDate(tm time.Time) {
 	f.modDate = tm
 }
 
-// SetJavascript adds Adobe JavaScript to the document.
+// SetJavascript adds Adobe JavaScript to the document. It replaces the script
+// added by an earlier call; use AddJavascript() to add several scripts.
 func (f *Fpdf) SetJavascript(script string) {
-	f.javascript = &script
+	f.AddJavascript("EmbeddedJS", script)
 }
 
 // RegisterAlias adds an (alias, replacement) pair to the document so we can
@@ -3884,6 +4182,7 @@ func (f *Fpdf) putpages() {
 		f.RegisterAlias(f.aliasNbPagesStr, sprintf("%d", nb))
 	}
 	f.replaceAliases()
+	f.pageLabelReplaceAliases()
 	if f.defOrientation == "P" {
 		wPt = f.defPageSize.Wd * f.k
 		hPt = f.defPageSize.Ht * f.k
@@ -3892,6 +4191,8 @@ func (f *Fpdf) putpages() {
 		hPt = f.defPageSize.Wd * f.k
 	}
 	pagesObjectNumbers := make([]int, nb+1) // 1-based
+	// Annotation objects follow the page objects
+	annotObj := f.n + 2*nb + 1
 	for n := 1; n <= nb; n++ {
 		// Page
 		f.newobj()
@@ -3905,16 +4206,29 @@ func (f *Fpdf) putpages() {
 		for t, pb := range f.pageBoxes[n] {
 			f.outf("/%s [%.2f %.2f %.2f %.2f]", t, pb.X, pb.Y, pb.Wd, pb.Ht)
 		}
+		f.putPageAttrs(n)
 		f.out("/Resources 2 0 R")
 		// Links
-		if len(f.pageLinks[n])+len(f.pageAttachments[n]) > 0 {
+		if len(f.pageLinks[n])+len(f.pageAttachments[n])+len(f.pageAnnots[n]) > 0 {
 			var annots fmtBuffer
 			annots.printf("/Annots [")
 			for _, pl := range f.pageLinks[n] {
 				annots.printf("<</Type /Annot /Subtype /Link /Rect [%.2f %.2f %.2f %.2f] /Border [0 0 0] ",
 					pl.x, pl.y, pl.x+pl.wd, pl.y-pl.ht)
+				if len(pl.quad) > 0 {
+					annots.printf("/QuadPoints [")
+					for j, v := range pl.quad {
+						if j > 0 {
+							annots.printf(" ")
+						}
+						annots.printf("%.2f", v)
+					}
+					annots.printf("] ")
+				}
 				if pl.link == 0 {
 					annots.printf("/A <</S /URI /URI %s>>>>", f.textstring(pl.linkStr))
+				} else if action, ok := f.linkAction(f.links[pl.link]); ok {
+					annots.printf("%s>>", action)
 				} else {
 					l := f.links[pl.link]
 					var sz SizeType
@@ -3930,7 +4244,9 @@ func (f *Fpdf) putpages() {
 				}
 			}
 			f.putAttachmentAnnotationLinks(&annots, n)
-			annots.printf("]")
+			var refs string
+			refs, annotObj = f.annotationRefs(n, annotObj)
+			annots.printf("%s]", strings.TrimSpace(refs))
 			f.out(annots.String())
 		}
 		if f.pdfVersion > "1.3" {
@@ -3950,6 +4266,7 @@ func (f *Fpdf) putpages() {
 		}
 		f.out("endobj")
 	}
+	f.putAnnotations(pagesObjectNumbers)
 	// Pages root
 	f.offsets[1] = f.buffer.Len()
 	f.out("1 0 obj")
@@ -4382,9 +4699,9 @@ func (f *Fpdf) putimage(info *ImageInfoType) {
 	f.outf("/Width %d", int(info.w))
 	f.outf("/Height %d", int(info.h))
 	if info.cs == "Indexed" {
-		f.outf("/ColorSpace [/Indexed /DeviceRGB %d %d 0 R]", len(info.pal)/3-1, f.n+1)
+		f.outf("/ColorSpace [/Indexed %s %d %d 0 R]", f.imageColorSpace(info), len(info.pal)/3-1, f.n+1)
 	} else {
-		f.outf("/ColorSpace /%s", info.cs)
+		f.outf("/ColorSpace %s", f.imageColorSpace(info))
 		if info.cs == "DeviceCMYK" {
 			f.out("/Decode [1 0 1 0 1 0 1 0]")
 		}
@@ -4468,6 +4785,9 @@ func (f *Fpdf) putxobjectdict() {
 			}
 		}
 	}
+	for j := 1; j < len(f.groupList); j++ {
+		f.outf("/TG%d %d 0 R", j, f.groupList[j].objNum)
+	}
 	{
 		for tplName, objID := range f.importedTplObjs {
 			// here replace obj id hash with n
@@ -4499,11 +4819,14 @@ func (f *Fpdf) putresourcedict() {
 	f.putxobjectdict()
 	f.out(">>")
 	count := len(f.blendList)
-	if count > 1 {
+	if count > 1 || len(f.softMaskList) > 0 {
 		f.out("/ExtGState <<")
 		for j := 1; j < count; j++ {
 			f.outf("/GS%d %d 0 R", j, f.blendList[j].objNum)
 		}
+		for j, mask := range f.softMaskList {
+			f.outf("/SM%d %d 0 R", j, mask.objNum)
+		}
 		f.out(">>")
 	}
 	count = len(f.gradientList)
@@ -4514,6 +4837,14 @@ func (f *Fpdf) putresourcedict() {
 		}
 		f.out(">>")
 	}
+	count = len(f.patternList)
+	if count > 1 {
+		f.out("/Pattern <<")
+		for j := 1; j < count; j++ {
+			f.outf("/P%d %d 0 R", j, f.patternList[j].objNum)
+		}
+		f.out(">>")
+	}
 	// Layers
 	f.layerPutResourceDict()
 	f.spotColorPutResourceDict()
@@ -4525,8 +4856,17 @@ func (f *Fpdf) putBlendModes() {
 		bl := f.blendList[j]
 		f.newobj()
 		f.blendList[j].objNum = f.n
-		f.outf("<</Type /ExtGState /ca %s /CA %s /BM /%s>>",
-			bl.fillStr, bl.strokeStr, bl.modeStr)
+		var buf fmtBuffer
+		buf.printf("<</Type /ExtGState /ca %s /CA %s /BM /%s", bl.fillStr, bl.strokeStr, bl.modeStr)
+		if bl.overprint.set {
+			buf.printf(" /OP %v /op %v /OPM %d", bl.overprint.stroke, bl.overprint.fill,
+				bl.overprint.mode)
+		}
+		if bl.intentStr != "" {
+			buf.printf(" /RI /%s", bl.intentStr)
+		}
+		buf.printf(">>")
+		f.out(buf.String())
 		f.out("endobj")
 	}
 }
@@ -4536,6 +4876,14 @@ func (f *Fpdf) putGradients() {
 	for j := 1; j < count; j++ {
 		var f1 int
 		gr := f.gradientList[j]
+		if gr.stops != nil {
+			f.putGradientStops(j)
+			continue
+		}
+		if gr.mesh != nil {
+			f.putMeshGradient(j)
+			continue
+		}
 		if gr.tp == 2 || gr.tp == 3 {
 			f.newobj()
 			f.outf("<</FunctionType 2 /Domain [0.0 1.0] /C0 [%s] /C1 [%s] /N 1>>", gr.clr1Str, gr.clr2Str)
@@ -4557,22 +4905,36 @@ func (f *Fpdf) putGradients() {
 }
 
 func (f *Fpdf) putjavascript() {
-	if f.javascript == nil {
+	if len(f.javascripts) == 0 {
 		return
 	}
+	names := make([]string, 0, len(f.javascripts))
+	for name := range f.javascripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
 	f.newobj()
 	f.nJs = f.n
 	f.out("<<")
-	f.outf("/Names [(EmbeddedJS) %d 0 R]", f.n+1)
-	f.out(">>")
-	f.out("endobj")
-	f.newobj()
-	f.out("<<")
-	f.out("/S /JavaScript")
-	f.outf("/JS %s", f.textstring(*f.javascript))
+	var b fmtBuffer
+	for j, name := range names {
+		if j > 0 {
+			b.printf(" ")
+		}
+		b.printf("%s %d 0 R", f.textstring(name), f.n+1+j)
+	}
+	f.outf("/Names [%s]", b.String())
 	f.out(">>")
 	f.out("endobj")
+	for _, name := range names {
+		f.newobj()
+		f.out("<<")
+		f.out("/S /JavaScript")
+		f.outf("/JS %s", f.textstring(f.javascripts[name]))
+		f.out(">>")
+		f.out("endobj")
+	}
 }
 
 func (f *Fpdf) putresources() {
@@ -4582,11 +4944,15 @@ func (f *Fpdf) putresources() {
 	f.layerPutLayers()
 	f.putBlendModes()
 	f.putGradients()
+	f.putPatterns()
+	f.putSoftMasks()
+	f.putGroups()
 	f.putSpotColors()
 	f.putfonts()
 	if f.err != nil {
 		return
 	}
+	f.putICCProfiles()
 	f.putimages()
 	f.putTemplates()
 	f.putImportedTemplates() // gofpdi
@@ -4650,13 +5016,15 @@ func (f *Fpdf) putinfo() {
 func (f *Fpdf) putcatalog() {
 	f.out("/Type /Catalog")
 	f.out("/Pages 1 0 R")
-	switch f.zoomMode {
-	case "fullpage":
-		f.out("/OpenAction [3 0 R /Fit]")
-	case "fullwidth":
-		f.out("/OpenAction [3 0 R /FitH null]")
-	case "real":
-		f.out("/OpenAction [3 0 R /XYZ null null 1]")
+	if !f.putDocActions() {
+		switch f.zoomMode {
+		case "fullpage":
+			f.out("/OpenAction [3 0 R /Fit]")
+		case "fullwidth":
+			f.out("/OpenAction [3 0 R /FitH null]")
+		case "real":
+			f.out("/OpenAction [3 0 R /XYZ null null 1]")
+		}
 	}
 	// } 	else if !is_string($this->zoomMode))
 	// 		$this->out('/OpenAction [3 0 R /XYZ null null '.sprintf('%.2f',$this->zoomMode/100).']');
@@ -4675,11 +5043,17 @@ func (f *Fpdf) putcatalog() {
 		}
 		f.out("/PageLayout /" + f.layoutMode)
 	}
+	// Page labels
+	f.putPageLabels()
 	// Bookmarks
 	if len(f.outlines) > 0 {
 		f.outf("/Outlines %d 0 R", f.outlineRoot)
-		f.out("/PageMode /UseOutlines")
 	}
+	f.putPageMode()
+	// Viewer preferences
+	f.putViewerPrefs()
+	// Output intent
+	f.putOutputIntents()
 	// Layers
 	f.layerPutCatalog()
 	// Name dictionary :
@@ -4687,9 +5061,13 @@ func (f *Fpdf) putcatalog() {
 	//	-> Embedded files
 	f.out("/Names <<")
 	// JavaScript
-	if f.javascript != nil {
+	if len(f.javascripts) > 0 {
 		f.outf("/JavaScript %d 0 R", f.nJs)
 	}
+	// Named destinations
+	if f.nDests > 0 {
+		f.outf("/Dests %d 0 R", f.nDests)
+	}
 	// Embedded files
 	f.outf("/EmbeddedFiles %s", f.getEmbeddedFiles())
 	f.out(">>")
@@ -4747,7 +5125,7 @@ func (f *Fpdf) putbookmarks() {
 			level = o.level
 		}
 		n := f.n + 1
-		for _, o := range f.outlines {
+		for i, o := range f.outlines {
 			f.newobj()
 			f.outf("<</Title %s", f.textstring(o.text))
 			f.outf("/Parent %d 0 R", n+o.parent)
@@ -4763,8 +5141,12 @@ func (f *Fpdf) putbookmarks() {
 			if o.last != -1 {
 				f.outf("/Last %d 0 R", n+o.last)
 			}
-			f.outf("/Dest [%d 0 R /XYZ 0 %.2f null]", 1+2*o.p, (f.h-o.y)*f.k)
-			f.out("/Count 0>>")
+			if o.opt != nil {
+				f.putBookmarkOpts(i)
+			} else {
+				f.outf("/Dest [%d 0 R /XYZ 0 %.2f null]", 1+2*o.p, (f.h-o.y)*f.k)
+				f.out("/Count 0>>")
+			}
 			f.out("endobj")
 		}
 		f.newobj()
@@ -4791,6 +5173,8 @@ func (f *Fpdf) enddoc() {
 	}
 	// Bookmarks
 	f.putbookmarks()
+	// Named destinations
+	f.putNamedDests()
 	// Metadata
 	f.putxmp()
 	// 	Info
@@ -4838,6 +5222,7 @@ func (f *Fpdf) enddoc() {
 // that PDF creates nice line joins at the angles, rather than just
 // overlaying the lines.
 func (f *Fpdf) MoveTo(x, y float64) {
+	f.pathBegin()
 	f.point(x, y)
 	f.x, f.y = x, y
 }
@@ -4908,6 +5293,17 @@ func (f *Fpdf) ClosePath() {
 // The MoveTo() example demonstrates this method.
 func (f *Fpdf) DrawPath(styleStr string) {
 	f.outf(fillDrawOp(styleStr))
+	f.fillMaskEnd(f.pathMasked)
+	f.pathMasked = false
+}
+
+// pathBegin applies the soft mask of the current fill color, if it has one,
+// to a path that is about to be constructed and painted with DrawPath(),
+// unless this has already been done for the path
+func (f *Fpdf) pathBegin() {
+	if !f.pathMasked {
+		f.pathMasked = f.fillMaskBegin("f")
+	}
 }
 
 // ArcTo draws an elliptical arc centered at point (x, y). rx and ry specify its
@@ -4944,6 +5340,7 @@ func (f *Fpdf) arc(x, y, rx, ry, degRotate, degStart, degEnd float64,
 	angleTotal := angleEnd - angleStart
 	dt := angleTotal / float64(segments)
 	dtm := dt / 3
+	masked := !path && f.fillMaskBegin(fillDrawOp(styleStr))
 	if degRotate != 0 {
 		a := -degRotate * math.Pi / 180
 		f.outf("q %.5f %.5f %.5f %.5f %.5f %.5f cm",
@@ -4995,4 +5392,5 @@ func (f *Fpdf) arc(x, y, rx, ry, degRotate, degStart, degEnd float64,
 	if degRotate != 0 {
 		f.out("Q")
 	}
+	f.fillMaskEnd(masked)
 }
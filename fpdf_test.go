@@ -27,6 +27,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -1487,6 +1489,70 @@ func ExampleFpdf_SplitLines() {
 	// Successfully generated pdf/Fpdf_Splitlines.pdf
 }
 
+// TestSVGBasicParsePath verifies the conversion of SVG path data to absolute
+// segments
+func TestSVGBasicParsePath(t *testing.T) {
+	type segType struct {
+		cmd byte
+		arg []float64
+	}
+	parse := func(d string) ([]gofpdf.SVGBasicSegmentType, error) {
+		sig, err := gofpdf.SVGBasicParse([]byte(`<svg width="100" height="100"><path d="` + d + `"/></svg>`))
+		if err != nil {
+			return nil, err
+		}
+		return sig.Segments[0], nil
+	}
+	list := []struct {
+		d    string
+		segs []segType
+	}{
+		// Implicit commands, compact numbers and exponents
+		{"m10 20 5-5h.5.5v1e1", []segType{{'M', []float64{10, 20}}, {'L', []float64{15, 15}},
+			{'H', []float64{15.5}}, {'H', []float64{16}}, {'V', []float64{25}}}},
+		// Relative moveto after closepath starts at the start of the subpath
+		{"M5 5l5 0zl1 1", []segType{{'M', []float64{5, 5}}, {'L', []float64{10, 5}}, {'Z', nil},
+			{'L', []float64{6, 6}}}},
+		// Smooth cubic curve reflects the second control point
+		{"M0 0C0 10 10 10 10 0s10-10 10 0", []segType{{'M', []float64{0, 0}},
+			{'C', []float64{0, 10, 10, 10, 10, 0}}, {'C', []float64{10, -10, 20, -10, 20, 0}}}},
+		// Smooth quadratic curve is converted to a cubic curve
+		{"M0 0Q5 10 10 0T20 0", []segType{{'M', []float64{0, 0}}, {'Q', []float64{5, 10, 10, 0}},
+			{'C', []float64{40.0 / 3, -20.0 / 3, 50.0 / 3, -20.0 / 3, 20, 0}}}},
+		// Half circle with compact flags is split into quarter circles
+		{"M0 0a10 10 0 1120 0", []segType{{'M', []float64{0, 0}},
+			{'C', []float64{0, -5.5228, 4.4772, -10, 10, -10}},
+			{'C', []float64{15.5228, -10, 20, -5.5228, 20, 0}}}},
+		// Arc with zero radius is a line
+		{"M0 0A0 5 0 0 0 10 10", []segType{{'M', []float64{0, 0}}, {'L', []float64{10, 10}}}},
+	}
+	for _, item := range list {
+		segs, err := parse(item.d)
+		if err != nil {
+			t.Fatalf("path \"%s\": %s", item.d, err)
+		}
+		if len(segs) != len(item.segs) {
+			t.Fatalf("path \"%s\": expecting %d segments, got %d", item.d, len(item.segs), len(segs))
+		}
+		for j, seg := range item.segs {
+			if segs[j].Cmd != seg.cmd {
+				t.Fatalf("path \"%s\": expecting command %c, got %c", item.d, seg.cmd, segs[j].Cmd)
+			}
+			for k, v := range seg.arg {
+				if math.Abs(segs[j].Arg[k]-v) > 0.0001 {
+					t.Fatalf("path \"%s\": unexpected segment %c %v", item.d, segs[j].Cmd, segs[j].Arg)
+				}
+			}
+		}
+	}
+	for _, d := range []string{"10 10", "M0 0L1", "M0 0z 5", "M0 0A5 5 0 2 0 10 10", "M0 0X1"} {
+		_, err := parse(d)
+		if err == nil {
+			t.Fatalf("path \"%s\": error expected", d)
+		}
+	}
+}
+
 // ExampleFpdf_SVGBasicWrite demonstrates how to render a simple path-only SVG image of the
 // type generated by the jSignature web control.
 func ExampleFpdf_SVGBasicWrite() {
@@ -2923,3 +2989,2856 @@ func ExampleFpdf_SetModificationDate() {
 	// Output:
 	// Successfully generated pdf/Fpdf_SetModificationDate.pdf
 }
+
+// pdfDocType is an uncompressed document produced by a test, broken into its
+// indirect objects so that tests can examine its structure
+type pdfDocType struct {
+	version string
+	objs    map[int]string
+	root    int
+}
+
+// pdfOpType is an operator of a content stream along with its operands
+type pdfOpType struct {
+	op   string
+	args []string
+}
+
+var pdfObjRe = regexp.MustCompile(`(?s)\n(\d+) 0 obj\n(.*?)\nendobj`)
+var pdfRefRe = regexp.MustCompile(`^\d+ \d+ R`)
+
+// pdfParse closes pdf and returns the objects of the document it produces
+func pdfParse(t *testing.T, pdf *gofpdf.Fpdf) (doc pdfDocType) {
+	var buf bytes.Buffer
+	pdf.SetCompression(false)
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	str := buf.String()
+	doc.version = strings.TrimPrefix(strings.SplitN(str, "\n", 2)[0], "%PDF-")
+	doc.objs = make(map[int]string)
+	for _, m := range pdfObjRe.FindAllStringSubmatch(str, -1) {
+		n, _ := strconv.Atoi(m[1])
+		doc.objs[n] = m[2]
+	}
+	if m := regexp.MustCompile(`/Root (\d+) 0 R`).FindStringSubmatch(str); m != nil {
+		doc.root, _ = strconv.Atoi(m[1])
+	}
+	return
+}
+
+// dict returns the entries of the dictionary referred to by ref
+func (doc pdfDocType) dict(ref string) map[string]string {
+	return pdfDict(doc.objs[pdfRef(ref)])
+}
+
+// catalog returns the entries of the document catalog
+func (doc pdfDocType) catalog() map[string]string {
+	return pdfDict(doc.objs[doc.root])
+}
+
+// pages returns the references to the pages of the document in order
+func (doc pdfDocType) pages() []string {
+	return pdfItems(doc.dict(doc.catalog()["/Pages"])["/Kids"])
+}
+
+// page returns the entries of the dictionary of page n, counting from one
+func (doc pdfDocType) page(n int) map[string]string {
+	return doc.dict(doc.pages()[n-1])
+}
+
+// pageNum returns the number of the page referred to by ref, or zero
+func (doc pdfDocType) pageNum(ref string) int {
+	for j, pageRef := range doc.pages() {
+		if pdfRef(pageRef) == pdfRef(ref) {
+			return j + 1
+		}
+	}
+	return 0
+}
+
+// stream returns the data of the stream referred to by ref
+func (doc pdfDocType) stream(ref string) string {
+	str := doc.objs[pdfRef(ref)]
+	if j := strings.Index(str, "stream\n"); j >= 0 {
+		str = str[j+7:]
+	}
+	return strings.TrimSuffix(str, "\nendstream")
+}
+
+// content returns the operators of the content stream of page n
+func (doc pdfDocType) content(n int) []pdfOpType {
+	return pdfOps(doc.stream(doc.page(n)["/Contents"]))
+}
+
+// resource returns the reference to the resource named nameStr in the
+// category catStr, such as /ExtGState, of page n
+func (doc pdfDocType) resource(n int, catStr, nameStr string) string {
+	return pdfDict(doc.dict(doc.page(n)["/Resources"])[catStr])[nameStr]
+}
+
+// find returns the entries of the dictionaries that contain key with the
+// value valStr
+func (doc pdfDocType) find(key, valStr string) (list []map[string]string) {
+	nums := make([]int, 0, len(doc.objs))
+	for n := range doc.objs {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	for _, n := range nums {
+		mp := pdfDict(doc.objs[n])
+		if mp[key] == valStr {
+			list = append(list, mp)
+		}
+	}
+	return
+}
+
+// pdfEnd returns the position that follows the object that begins at pos
+func pdfEnd(s string, pos int) int {
+	switch {
+	case strings.HasPrefix(s[pos:], "<<"):
+		for pos = pdfSkip(s, pos+2); pos < len(s) && !strings.HasPrefix(s[pos:], ">>"); pos = pdfSkip(s, pos) {
+			pos = pdfEnd(s, pos)
+		}
+		return pos + 2
+	case s[pos] == '[':
+		for pos = pdfSkip(s, pos+1); pos < len(s) && s[pos] != ']'; pos = pdfSkip(s, pos) {
+			pos = pdfEnd(s, pos)
+		}
+		return pos + 1
+	case s[pos] == '(':
+		depth := 0
+		for ; pos < len(s); pos++ {
+			switch s[pos] {
+			case '\\':
+				pos++
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return pos + 1
+				}
+			}
+		}
+		return pos
+	case s[pos] == '<':
+		return pos + strings.IndexByte(s[pos:], '>') + 1
+	}
+	if m := pdfRefRe.FindString(s[pos:]); m != "" {
+		return pos + len(m)
+	}
+	end := pos + 1
+	for end < len(s) && !strings.ContainsRune(" \t\r\n/[]()<>", rune(s[end])) {
+		end++
+	}
+	return end
+}
+
+// pdfSkip returns the position of the first character at or after pos that
+// is not white space
+func pdfSkip(s string, pos int) int {
+	for pos < len(s) && strings.ContainsRune(" \t\r\n", rune(s[pos])) {
+		pos++
+	}
+	return pos
+}
+
+// pdfItems returns the objects contained in the array or dictionary that
+// begins s
+func pdfItems(s string) (list []string) {
+	s = strings.TrimSpace(s)
+	pos := 1
+	if strings.HasPrefix(s, "<<") {
+		pos = 2
+	} else if !strings.HasPrefix(s, "[") {
+		return
+	}
+	for pos = pdfSkip(s, pos); pos < len(s) && s[pos] != ']' && s[pos] != '>'; pos = pdfSkip(s, pos) {
+		end := pdfEnd(s, pos)
+		list = append(list, s[pos:end])
+		pos = end
+	}
+	return
+}
+
+// pdfDict returns the entries of the dictionary that begins s
+func pdfDict(s string) map[string]string {
+	mp := make(map[string]string)
+	if !strings.HasPrefix(strings.TrimSpace(s), "<<") {
+		return mp
+	}
+	list := pdfItems(s)
+	for j := 0; j+1 < len(list); j += 2 {
+		mp[list[j]] = list[j+1]
+	}
+	return mp
+}
+
+// pdfRef returns the object number of the reference s
+func pdfRef(s string) (n int) {
+	fmt.Sscanf(s, "%d 0 R", &n)
+	return
+}
+
+// pdfNum returns the value of the number s
+func pdfNum(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+// pdfNums returns the values of the numbers in the array s
+func pdfNums(s string) (list []float64) {
+	for _, item := range pdfItems(s) {
+		list = append(list, pdfNum(item))
+	}
+	return
+}
+
+// pdfOps breaks the content stream s into operators and their operands
+func pdfOps(s string) (list []pdfOpType) {
+	var args []string
+	for pos := pdfSkip(s, 0); pos < len(s); pos = pdfSkip(s, pos) {
+		end := pdfEnd(s, pos)
+		tok := s[pos:end]
+		if _, err := strconv.ParseFloat(tok, 64); err == nil || strings.ContainsAny(tok[:1], "/[(<") {
+			args = append(args, tok)
+		} else {
+			list = append(list, pdfOpType{tok, args})
+			args = nil
+		}
+		pos = end
+	}
+	return
+}
+
+// pdfFind returns the index of the first operator named opStr at or after
+// position start in list whose operands begin with args, or -1 if there is
+// none. Numeric operands are compared with a tolerance that accommodates the
+// rounding of output values.
+func pdfFind(list []pdfOpType, start int, opStr string, args ...string) int {
+	for j := start; j < len(list); j++ {
+		if list[j].op != opStr || len(list[j].args) < len(args) {
+			continue
+		}
+		match := true
+		for k, arg := range args {
+			a, errA := strconv.ParseFloat(arg, 64)
+			b, errB := strconv.ParseFloat(list[j].args[k], 64)
+			if errA == nil && errB == nil {
+				match = match && math.Abs(a-b) < 0.015
+			} else {
+				match = match && arg == list[j].args[k]
+			}
+		}
+		if match {
+			return j
+		}
+	}
+	return -1
+}
+
+// pdfCount returns the number of operators named opStr in list
+func pdfCount(list []pdfOpType, opStr string) (count int) {
+	for _, op := range list {
+		if op.op == opStr {
+			count++
+		}
+	}
+	return
+}
+
+// TestSetTabStops verifies the order and defaults of tab stops and the
+// placement of tabbed fragments, which is mirrored in right-to-left mode
+func TestSetTabStops(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTabStops([]gofpdf.TabStop{{Pos: 60, Align: "d"}, {Pos: 30}})
+	stops := pdf.GetTabStops()
+	if len(stops) != 2 || stops[0].Pos != 30 || stops[1].Align != "D" || stops[1].DecimalStr != "." {
+		t.Fatalf("unexpected tab stops %v", stops)
+	}
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.CellFormat(100, 10, "ab\tcd\t12.5", "", 1, "", false, 0, "")
+	// Stops are measured from the left edge of the cell, or from its right
+	// edge in right-to-left mode
+	expect := map[string]float64{
+		"(ab)":   10 + 1,
+		"(cd)":   10 + 30,
+		"(12.5)": 10 + 60 - pdf.GetStringWidth("12"),
+	}
+	pdf.AddUTF8Font("dejavu", "", example.FontFile("DejaVuSansCondensed.ttf"))
+	pdf.SetFont("dejavu", "", 12)
+	pdf.RTL()
+	pdf.CellFormat(100, 10, "ab\tcd", "", 1, "", false, 0, "")
+	expect["(\x00b\x00a)"] = 110 - 1 - pdf.GetStringWidth("ab")
+	expect["(\x00d\x00c)"] = 110 - 30 - pdf.GetStringWidth("cd")
+	ops := pdfParse(t, pdf).content(1)
+	for txtStr, x := range expect {
+		j := pdfFind(ops, 0, "Tj", txtStr)
+		if j < 1 || pdfFind(ops, j-1, "Td", fmt.Sprintf("%.2f", x*72/25.4)) != j-1 {
+			t.Fatalf("fragment %q not placed at %.2f", txtStr, x)
+		}
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTabStops([]gofpdf.TabStop{{Pos: 30, Align: "X"}})
+	if !pdf.Err() {
+		t.Fatalf("invalid tab stop alignment not reported")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.SetTabStops([]gofpdf.TabStop{{Pos: 30}})
+	pdf.AddPage()
+	pdf.RTL()
+	pdf.Write(5, "ab\tcd")
+	if !pdf.Err() {
+		t.Fatalf("tabs written in right-to-left mode not reported")
+	}
+}
+
+// ExampleFpdf_SetTabStops demonstrates tab stops with dot leaders in a table
+// of contents and decimal alignment in an invoice.
+func ExampleFpdf_SetTabStops() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.SetTabStops([]gofpdf.TabStop{
+		{Pos: 20},
+		{Pos: 150, Align: "R", Leader: "."},
+	})
+	pdf.CellFormat(0, 10, "Contents", "", 1, "L", false, 0, "")
+	for j, title := range []string{"Introduction", "Getting started", "Reference", "Index"} {
+		pdf.CellFormat(0, 7, fmt.Sprintf("%d\t%s\t%d", j+1, title, 3+j*17), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(10)
+	pdf.SetTabStops([]gofpdf.TabStop{
+		{Pos: 100, Align: "D", Leader: " ."},
+		{Pos: 150, Align: "D"},
+	})
+	pdf.MultiCell(0, 7, "Item\tQty\tAmount\nPaper\t12\t35.50\nInk\t2\t119.95\n"+
+		"Binding\t1\t7.2\nTotal\t\t162.65", "1", "L", false)
+	pdf.Ln(10)
+	pdf.SetTabStops([]gofpdf.TabStop{{Pos: 40, Leader: "-"}})
+	pdf.Write(7, "Write\tfollows the same tab stops, measured from the left margin.")
+	fileStr := example.Filename("Fpdf_SetTabStops")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetTabStops.pdf
+}
+
+// TestWidowOrphanControl verifies that MultiCell() moves paragraphs or page
+// breaks to avoid stranding single lines.
+func TestWidowOrphanControl(t *testing.T) {
+	txtStr := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 12)
+	layout := func(minLines int, keep bool, y float64) (pdf *gofpdf.Fpdf, lines int) {
+		pdf = gofpdf.New("P", "mm", "A4", "")
+		pdf.SetFont("Arial", "", 12)
+		pdf.AddPage()
+		lines = len(pdf.SplitLines([]byte(txtStr), 190))
+		pdf.SetWidowOrphanControl(minLines)
+		pdf.SetY(y)
+		if keep {
+			pdf.SetKeepWithNext(true)
+			pdf.MultiCell(0, 10, "Heading", "", "", false)
+		}
+		pdf.MultiCell(190, 10, txtStr, "", "", false)
+		return
+	}
+	_, ht := gofpdf.New("P", "mm", "A4", "").GetPageSize()
+	trigger := ht - 20
+
+	// One line would remain at the bottom: the paragraph moves to page 2
+	pdf, lines := layout(2, false, trigger-15)
+	if pdf.PageNo() != 2 || math.Abs(pdf.GetY()-(10+float64(lines)*10)) > 0.01 {
+		t.Fatalf("orphan not avoided: page %d, y %.2f", pdf.PageNo(), pdf.GetY())
+	}
+
+	// One line would be carried over: the break moves up by one line
+	pdf, lines = layout(2, false, trigger-float64(lines-1)*10-5)
+	if pdf.PageNo() != 2 || math.Abs(pdf.GetY()-30) > 0.01 {
+		t.Fatalf("widow not avoided: page %d, y %.2f", pdf.PageNo(), pdf.GetY())
+	}
+
+	// Without control, the same layout leaves a single line on page 2
+	pdf, lines = layout(0, false, trigger-float64(lines-1)*10-5)
+	if pdf.PageNo() != 2 || math.Abs(pdf.GetY()-20) > 0.01 {
+		t.Fatalf("unexpected layout without control: page %d, y %.2f", pdf.PageNo(), pdf.GetY())
+	}
+
+	// A heading that fits is moved to accompany its paragraph
+	pdf, lines = layout(0, true, trigger-15)
+	if pdf.PageNo() != 2 || math.Abs(pdf.GetY()-(20+float64(lines)*10)) > 0.01 {
+		t.Fatalf("heading not kept with next: page %d, y %.2f", pdf.PageNo(), pdf.GetY())
+	}
+
+	// Lines that fit on a page are counted below the header
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetHeaderFunc(func() {
+		pdf.SetY(40)
+	})
+	pdf.SetFont("Arial", "", 12)
+	pdf.SetWidowOrphanControl(2)
+	pdf.AddPage()
+	// 23 lines fit below the header, so one line would be carried to page 3
+	pdf.MultiCell(0, 10, strings.Repeat("Line\n", 46)+"Line", "", "", false)
+	if pdf.PageNo() != 3 || math.Abs(pdf.GetY()-60) > 0.01 {
+		t.Fatalf("widow below header not avoided: page %d, y %.2f", pdf.PageNo(), pdf.GetY())
+	}
+}
+
+// ExampleFpdf_SetWidowOrphanControl demonstrates paragraphs and headings that
+// are kept together across page breaks.
+func ExampleFpdf_SetWidowOrphanControl() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetWidowOrphanControl(2)
+	pdf.AddPage()
+	txtStr, _ := ioutil.ReadFile(example.TextFile("20k_c1.txt"))
+	paraList := strings.Split(string(txtStr), "\n")
+	for j := 0; j < len(paraList); j++ {
+		if j%3 == 0 {
+			pdf.SetFont("Arial", "B", 14)
+			pdf.SetKeepWithNext(true)
+			pdf.MultiCell(0, 6, fmt.Sprintf("Section %d", j/3+1), "", "L", false)
+		}
+		pdf.SetFont("Times", "", 12)
+		pdf.MultiCell(0, 6, paraList[j], "", "", false)
+		pdf.Ln(3)
+	}
+	fileStr := example.Filename("Fpdf_SetWidowOrphanControl")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetWidowOrphanControl.pdf
+}
+
+// TestTransaction verifies that a rolled back layout transaction leaves no
+// trace in the document and that a committed one is retained.
+func TestTransaction(t *testing.T) {
+	output := func(pdf *gofpdf.Fpdf) string {
+		var buf bytes.Buffer
+		pdf.SetCompression(false)
+		pdf.SetCreationDate(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+		if err := pdf.Output(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	build := func(fn func(pdf *gofpdf.Fpdf)) *gofpdf.Fpdf {
+		pdf := gofpdf.New("P", "mm", "A4", "")
+		// Fonts registered during a transaction survive a rollback
+		pdf.SetFont("Times", "B", 20)
+		pdf.SetFont("Arial", "", 12)
+		pdf.AddPage()
+		pdf.Cell(40, 10, "Before")
+		fn(pdf)
+		pdf.Ln(10)
+		pdf.Cell(40, 10, "After")
+		return pdf
+	}
+	plain := output(build(func(pdf *gofpdf.Fpdf) {}))
+	rolled := output(build(func(pdf *gofpdf.Fpdf) {
+		pdf.Begin()
+		pdf.SetFont("Times", "B", 20)
+		pdf.SetTextColor(255, 0, 0)
+		pdf.Bookmark("Discarded", 0, -1)
+		pdf.Link(10, 10, 20, 20, pdf.AddLink())
+		pdf.SetOpenAction(gofpdf.Action{Page: 1, Fit: gofpdf.FitPage})
+		pdf.AddJavascript("discarded", "app.alert('discarded');")
+		pdf.SetViewerPreferences(gofpdf.ViewerPrefs{HideToolbar: true})
+		pdf.AddLayer("Discarded", true)
+		pdf.OpenLayerPane()
+		pdf.InsertTOC(1, gofpdf.TOCStyle{})
+		for j := 0; j < 60; j++ {
+			pdf.MultiCell(0, 10, "Discarded text", "", "", false)
+		}
+		pdf.SetErrorf("discarded error")
+		pdf.Rollback()
+	}))
+	if rolled != plain {
+		t.Fatalf("rolled back transaction altered the document")
+	}
+	// Pages cannot be rearranged within a transaction
+	var insertErr error
+	rolled = output(build(func(pdf *gofpdf.Fpdf) {
+		pdf.Begin()
+		pdf.InsertPageAt(1)
+		pdf.Cell(40, 10, "inserted")
+		insertErr = pdf.Error()
+		pdf.Rollback()
+	}))
+	if insertErr == nil {
+		t.Fatalf("page insertion within transaction not reported")
+	}
+	if rolled != plain {
+		t.Fatalf("rolled back page insertion altered the document")
+	}
+	pdf := build(func(pdf *gofpdf.Fpdf) {
+		pdf.Begin()
+		pdf.AddPage()
+		pdf.Commit()
+	})
+	if pdf.PageNo() != 2 {
+		t.Fatalf("committed page missing: page %d", pdf.PageNo())
+	}
+	pdf = build(func(pdf *gofpdf.Fpdf) { pdf.Begin() })
+	pdf.Close()
+	if !pdf.Err() {
+		t.Fatalf("open transaction not reported")
+	}
+}
+
+// ExampleFpdf_Measure demonstrates the measurement of a block of text before
+// it is written so that a box of the right size can be drawn behind it.
+func ExampleFpdf_Measure() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	txtStr, _ := ioutil.ReadFile(example.TextFile("20k_c1.txt"))
+	paraList := strings.Split(string(txtStr), "\n")
+	pdf.SetFillColor(230, 230, 250)
+	for j := 0; j < 6; j++ {
+		pdf.SetFont("Times", "", 12)
+		para := func() {
+			pdf.MultiCell(0, 5, paraList[j], "", "", false)
+		}
+		ht, pages := pdf.Measure(para)
+		if pages > 1 {
+			// Keep the boxed paragraph on a single page
+			pdf.AddPage()
+		}
+		pdf.Rect(pdf.GetX(), pdf.GetY(), 190, ht, "F")
+		para()
+		pdf.Ln(4)
+	}
+	// A trial layout that is discarded because it overflows the page
+	pdf.Begin()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.MultiCell(0, 8, strings.Repeat("Discarded heading ", 20), "", "", false)
+	if pdf.PageNo() > 1 && pdf.GetY() > 250 {
+		pdf.Rollback()
+		pdf.SetFont("Arial", "B", 10)
+		pdf.MultiCell(0, 5, "Shortened heading", "", "", false)
+	} else {
+		pdf.Commit()
+	}
+	fileStr := example.Filename("Fpdf_Measure")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_Measure.pdf
+}
+
+// TestInsertTOC verifies that a table of contents spanning several pages is
+// moved to its requested position and refers to the final page numbers.
+func TestInsertTOC(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.Cell(40, 10, "Title page")
+	for j := 1; j <= 40; j++ {
+		pdf.AddPage()
+		pdf.AddTOCEntry(fmt.Sprintf("Chapter %d", j), 0)
+		pdf.Cell(40, 10, fmt.Sprintf("Body of chapter %d", j))
+	}
+	pdf.InsertTOC(2, gofpdf.TOCStyle{Title: "Contents", LineHt: 10})
+	doc := pdfParse(t, pdf)
+	// 40 entries of 10 mm each, plus the title, need two pages
+	if pdf.PageNo() != 43 {
+		t.Fatalf("expected 43 pages, got %d", pdf.PageNo())
+	}
+	for n, txtStr := range map[int]string{1: "(Title page)", 2: "(Contents)", 4: "(Body of chapter 1)",
+		43: "(Body of chapter 40)"} {
+		if pdfFind(doc.content(n), 0, "Tj", txtStr) < 0 {
+			t.Fatalf("%s missing from page %d", txtStr, n)
+		}
+	}
+	ops := doc.content(2)
+	if j := pdfFind(ops, 0, "Tj", "(Chapter 1)"); j < 0 || pdfFind(ops, j, "Tj", "(4)") < 0 {
+		t.Fatalf("first entry does not show its final page number")
+	}
+	if pdfFind(doc.content(3), 0, "Tj", "(43)") < 0 {
+		t.Fatalf("last entry does not show its final page number")
+	}
+	// The entry and the bookmark of the first chapter refer to its moved page
+	annot := pdfDict(pdfItems(doc.page(2)["/Annots"])[0])
+	if doc.pageNum(pdfItems(annot["/Dest"])[0]) != 4 {
+		t.Fatalf("entry does not link to moved page")
+	}
+	outlines := doc.find("/Title", "(Chapter 1)")
+	if len(outlines) != 1 || doc.pageNum(pdfItems(outlines[0]["/Dest"])[0]) != 4 {
+		t.Fatalf("bookmark does not point to moved page")
+	}
+	// Entries too long for a line wrap, so the table takes more pages
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	for j := 1; j <= 40; j++ {
+		pdf.AddPage()
+		pdf.AddTOCEntry(fmt.Sprintf("Chapter %d %s", j, strings.Repeat("long title ", 16)), 0)
+	}
+	pdf.InsertTOC(2, gofpdf.TOCStyle{Title: "Contents", LineHt: 10})
+	pdf.Close()
+	// 40 entries of two lines each need four pages
+	if pdf.Err() || pdf.PageNo() != 45 {
+		t.Fatalf("expected 45 pages, got %d", pdf.PageNo())
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.InsertTOC(0, gofpdf.TOCStyle{})
+	if !pdf.Err() {
+		t.Fatalf("invalid table of contents page not reported")
+	}
+}
+
+// ExampleFpdf_InsertTOC demonstrates a table of contents that is generated
+// when the document is closed and placed after the title page.
+func ExampleFpdf_InsertTOC() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 24)
+	pdf.CellFormat(0, 100, "Twenty Thousand Leagues Under the Sea", "", 1, "C", false, 0, "")
+	txtStr, _ := ioutil.ReadFile(example.TextFile("20k_c1.txt"))
+	paraList := strings.Split(string(txtStr), "\n")
+	for j := 1; j <= 12; j++ {
+		pdf.AddPage()
+		pdf.SetFont("Arial", "B", 16)
+		pdf.AddTOCEntry(fmt.Sprintf("Chapter %d", j), 0)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Chapter %d", j), "", 1, "", false, 0, "")
+		for k, para := range paraList {
+			if k%4 == 0 {
+				pdf.SetFont("Arial", "B", 12)
+				pdf.AddTOCEntry(fmt.Sprintf("Section %d.%d", j, k/4+1), 1)
+				pdf.CellFormat(0, 8, fmt.Sprintf("Section %d.%d", j, k/4+1), "", 1, "", false, 0, "")
+			}
+			pdf.SetFont("Times", "", 12)
+			pdf.MultiCell(0, 5, para, "", "", false)
+			pdf.Ln(2)
+		}
+	}
+	pdf.InsertTOC(2, gofpdf.TOCStyle{Title: "Contents", FontFamily: "Arial"})
+	fileStr := example.Filename("Fpdf_InsertTOC")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_InsertTOC.pdf
+}
+
+// TestPageReorder verifies that pages can be inserted, moved and deleted
+// while link destinations, bookmarks and footers remain consistent.
+func TestPageReorder(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	footers := 0
+	pdf.SetFooterFunc(func() {
+		footers++
+		pdf.SetY(-15)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Footer %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.SetFont("Arial", "", 12)
+	var links []int
+	for j := 1; j <= 4; j++ {
+		pdf.AddPage()
+		pdf.Bookmark(fmt.Sprintf("Page %c", 'A'+j-1), 0, 0)
+		link := pdf.AddLink()
+		pdf.SetLink(link, 0, -1)
+		links = append(links, link)
+		pdf.Cell(40, 10, fmt.Sprintf("Content %c", 'A'+j-1))
+	}
+	pdf.InsertPageAt(1)
+	if pdf.PageNo() != 1 || pdf.PageCount() != 5 {
+		t.Fatalf("inserted page not current: page %d of %d", pdf.PageNo(), pdf.PageCount())
+	}
+	pdf.Cell(40, 10, "Summary")
+	for _, link := range links {
+		pdf.CellFormat(40, 10, "Link", "", 1, "", false, link, "")
+	}
+	// Order is now Summary, A, B, C, D
+	pdf.MovePage(5, 2)
+	// Order is now Summary, D, A, B, C
+	pdf.DeletePage(4)
+	// Order is now Summary, D, A, C
+	if pdf.PageCount() != 4 || pdf.PageNo() != 1 {
+		t.Fatalf("unexpected page %d of %d", pdf.PageNo(), pdf.PageCount())
+	}
+	doc := pdfParse(t, pdf)
+	if footers != 5 {
+		t.Fatalf("expected 5 footers, got %d", footers)
+	}
+	for n, txtStr := range []string{"(Summary)", "(Content D)", "(Content A)", "(Content C)"} {
+		if pdfFind(doc.content(n+1), 0, "Tj", txtStr) < 0 {
+			t.Fatalf("%s missing from page %d", txtStr, n+1)
+		}
+	}
+	// The bookmark for page D moved to page 2 and the one for deleted page B
+	// is redirected to the page that preceded it
+	for title, n := range map[string]int{"D": 2, "A": 3, "B": 3, "C": 4} {
+		outlines := doc.find("/Title", fmt.Sprintf("(Page %s)", title))
+		if len(outlines) != 1 || doc.pageNum(pdfItems(outlines[0]["/Dest"])[0]) != n {
+			t.Fatalf("bookmark for page %s not remapped", title)
+		}
+	}
+	// The links on the summary page follow the pages of A, B, C and D
+	annots := pdfItems(doc.page(1)["/Annots"])
+	if len(annots) != 4 {
+		t.Fatalf("expected 4 links on summary page, got %d", len(annots))
+	}
+	for j, n := range []int{3, 3, 4, 2} {
+		if doc.pageNum(pdfItems(pdfDict(annots[j])["/Dest"])[0]) != n {
+			t.Fatalf("link %d not remapped", j)
+		}
+	}
+	pdf.MovePage(1, 9)
+	if !pdf.Err() {
+		t.Fatalf("invalid page move not reported")
+	}
+}
+
+// ExampleFpdf_InsertPageAt demonstrates a summary page that is placed at the
+// front of a report after the totals it shows have been computed.
+func ExampleFpdf_InsertPageAt() {
+	rnd := rand.New(rand.NewSource(0)) // Make reproducible documents
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	regionList := []string{"North", "East", "South", "West", "Unassigned"}
+	var totals []float64
+	var links []int
+	for _, region := range regionList {
+		pdf.AddPage()
+		link := pdf.AddLink()
+		pdf.SetLink(link, 0, -1)
+		links = append(links, link)
+		pdf.Bookmark(region, 0, 0)
+		pdf.SetFont("Arial", "B", 16)
+		pdf.CellFormat(0, 12, region+" region", "", 1, "", false, 0, "")
+		pdf.SetFont("Arial", "", 11)
+		var total float64
+		for j := 1; j <= 20; j++ {
+			amount := rnd.Float64() * 1000
+			total += amount
+			pdf.CellFormat(100, 7, fmt.Sprintf("Invoice %d", j), "B", 0, "", false, 0, "")
+			pdf.CellFormat(40, 7, fmt.Sprintf("%.2f", amount), "B", 1, "R", false, 0, "")
+		}
+		totals = append(totals, total)
+	}
+	// The last region has no sales and is dropped; the West region is moved
+	// ahead of the others
+	pdf.DeletePage(5)
+	pdf.MovePage(4, 1)
+	regionList = append(regionList[3:4], regionList[:3]...)
+	totals = append(totals[3:4], totals[:3]...)
+	links = append(links[3:4], links[:3]...)
+	// Summary page at the front of the report
+	pdf.InsertPageAt(1)
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 12, "Summary", "", 1, "", false, 0, "")
+	pdf.SetFont("Arial", "", 11)
+	for j, region := range regionList {
+		pdf.SetTextColor(0, 0, 200)
+		pdf.CellFormat(100, 7, region, "B", 0, "", false, links[j], "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(40, 7, fmt.Sprintf("%.2f", totals[j]), "B", 1, "R", false, 0, "")
+	}
+	fileStr := example.Filename("Fpdf_InsertPageAt")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_InsertPageAt.pdf
+}
+
+// TestPageLabel verifies page label formatting, the catalog entry and the
+// section page count alias.
+func TestPageLabel(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AliasNbSectionPages("")
+	pdf.SetPageLabel(3, gofpdf.PageLabelRomanLower, "", 1)
+	pdf.SetPageLabel(7, gofpdf.PageLabelDecimal, "A-", 1)
+	pdf.SetPageLabel(9, gofpdf.PageLabelLetterUpper, "", 26)
+	pdf.SetPageLabel(11, gofpdf.PageLabelNone, "Back", 1)
+	for j := 1; j <= 12; j++ {
+		pdf.AddPage()
+		pdf.Cell(40, 10, fmt.Sprintf("<%s of {snb}>", pdf.PageLabel(j)))
+	}
+	expected := []string{"1", "2", "i", "ii", "iii", "iv", "A-1", "A-2", "Z", "AA", "Back", "Back"}
+	for j, lbl := range expected {
+		if got := pdf.PageLabel(j + 1); got != lbl {
+			t.Fatalf("page %d: expected label %s, got %s", j+1, lbl, got)
+		}
+	}
+	// Removing the first page of a range carries the range to the next page
+	pdf.DeletePage(7)
+	if got := pdf.PageLabel(7); got != "A-1" {
+		t.Fatalf("label range not carried over: got %s", got)
+	}
+	doc := pdfParse(t, pdf)
+	// Ranges are keyed by zero-based page index in the catalog
+	nums := pdfItems(pdfDict(doc.catalog()["/PageLabels"])["/Nums"])
+	if len(nums) != 10 {
+		t.Fatalf("expected 5 label ranges, got %d", len(nums)/2)
+	}
+	for j, style := range []string{"/D", "/r", "/D", "/A", ""} {
+		lbl := pdfDict(nums[2*j+1])
+		if lbl["/S"] != style {
+			t.Fatalf("range at page index %s: expected style %q, got %q", nums[2*j], style, lbl["/S"])
+		}
+	}
+	if nums[4] != "6" || pdfDict(nums[5])["/P"] != "(A-)" {
+		t.Fatalf("prefixed range not carried to following page")
+	}
+	// The section page count alias counts the pages of each label range
+	for n, txtStr := range map[int]string{2: "(<2 of 2>)", 6: "(<iv of 4>)", 7: "(<A-2 of 1>)",
+		9: "(<AA of 2>)", 10: "(<Back of 2>)"} {
+		if pdfFind(doc.content(n), 0, "Tj", txtStr) < 0 {
+			t.Fatalf("%s missing from page %d", txtStr, n)
+		}
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetPageLabel(0, gofpdf.PageLabelDecimal, "", 1)
+	if !pdf.Err() {
+		t.Fatalf("invalid label page not reported")
+	}
+}
+
+// ExampleFpdf_SetPageLabel demonstrates front matter numbered with roman
+// numerals and chapters numbered separately, with footers that show the same
+// labels as the viewer.
+func ExampleFpdf_SetPageLabel() {
+	pdf := gofpdf.New("P", "mm", "A5", "")
+	pdf.AliasNbSectionPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %s of {snb}", pdf.PageLabel(pdf.PageNo())),
+			"", 0, "C", false, 0, "")
+	})
+	pdf.SetPageLabel(1, gofpdf.PageLabelRomanLower, "", 1)
+	for _, title := range []string{"Title", "Preface", "Acknowledgments"} {
+		pdf.AddPage()
+		pdf.SetFont("Arial", "B", 16)
+		pdf.CellFormat(0, 10, title, "", 1, "C", false, 0, "")
+	}
+	txtStr, _ := ioutil.ReadFile(example.TextFile("20k_c1.txt"))
+	for j := 1; j <= 3; j++ {
+		pdf.SetPageLabel(pdf.PageNo()+1, gofpdf.PageLabelDecimal, fmt.Sprintf("%d-", j), 1)
+		pdf.AddPage()
+		pdf.SetFont("Arial", "B", 16)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Chapter %d", j), "", 1, "", false, 0, "")
+		pdf.SetFont("Times", "", 12)
+		pdf.MultiCell(0, 5, string(txtStr), "", "", false)
+	}
+	fileStr := example.Filename("Fpdf_SetPageLabel")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetPageLabel.pdf
+}
+
+// TestAddPageWithOptions verifies the page attributes written for pages added
+// with AddPageWithOptions().
+func TestAddPageWithOptions(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.AddPageWithOptions(gofpdf.PageOptions{
+		OrientationStr: "L",
+		Rotate:         -90,
+		Duration:       5,
+		Transition: &gofpdf.PageTransition{Style: "split", Duration: 2,
+			TransitionOptions: gofpdf.TransitionOptions{Dimension: "v", Motion: "O", Direction: 90}},
+	})
+	pdf.AddPageWithOptions(gofpdf.PageOptions{UserUnit: 2,
+		Transition: &gofpdf.PageTransition{Style: "Fly", TransitionOptions: gofpdf.TransitionOptions{
+			Direction: 270, Scale: 0.5, Opaque: true}}})
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.6" {
+		t.Fatalf("expected PDF version 1.6 for user unit, got %s", doc.version)
+	}
+	if _, ok := doc.page(1)["/Rotate"]; ok {
+		t.Fatalf("unexpected rotation of first page")
+	}
+	page := doc.page(2)
+	if box := pdfNums(page["/MediaBox"]); len(box) != 4 || box[2] <= box[3] {
+		t.Fatalf("second page not in landscape orientation")
+	}
+	if page["/Rotate"] != "270" || pdfNum(page["/Dur"]) != 5 {
+		t.Fatalf("unexpected rotation %s or duration %s", page["/Rotate"], page["/Dur"])
+	}
+	trans := pdfDict(page["/Trans"])
+	if trans["/S"] != "/Split" || pdfNum(trans["/D"]) != 2 || trans["/Dm"] != "/V" || trans["/M"] != "/O" {
+		t.Fatalf("unexpected transition %v", trans)
+	}
+	page = doc.page(3)
+	trans = pdfDict(page["/Trans"])
+	if pdfNum(page["/UserUnit"]) != 2 || trans["/S"] != "/Fly" || trans["/Di"] != "270" ||
+		pdfNum(trans["/SS"]) != 0.5 || trans["/B"] != "true" {
+		t.Fatalf("unexpected user unit %s or transition %v", page["/UserUnit"], trans)
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPageWithOptions(gofpdf.PageOptions{Rotate: 45})
+	if !pdf.Err() {
+		t.Fatalf("invalid rotation not reported")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPageWithOptions(gofpdf.PageOptions{Transition: &gofpdf.PageTransition{Style: "Spin"}})
+	if !pdf.Err() {
+		t.Fatalf("invalid transition not reported")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPageWithOptions(gofpdf.PageOptions{Transition: &gofpdf.PageTransition{Style: "Glitter",
+		TransitionOptions: gofpdf.TransitionOptions{Direction: 90}}})
+	if !pdf.Err() {
+		t.Fatalf("invalid transition direction not reported")
+	}
+}
+
+// ExampleFpdf_AddPageWithOptions demonstrates a wide table in a portrait
+// document. The table is drawn sideways on a portrait page that is rotated
+// when displayed, so it reads correctly on screen and prints in the same
+// orientation as the surrounding pages.
+func ExampleFpdf_AddPageWithOptions() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.MultiCell(0, 6, "The table on the following page is wider than a portrait page. "+
+		"The page is rotated by 90 degrees for display.", "", "", false)
+	pdf.AddPageWithOptions(gofpdf.PageOptions{Rotate: 90})
+	_, ht := pdf.GetPageSize()
+	pdf.SetAutoPageBreak(false, 0)
+	// Draw in the coordinate system of a landscape page whose top edge is the
+	// left edge of the portrait page
+	pdf.TransformBegin()
+	pdf.TransformRotate(90, ht/2, ht/2)
+	pdf.SetXY(15, 15)
+	pdf.SetFont("Arial", "B", 10)
+	for j := 1; j <= 12; j++ {
+		pdf.CellFormat(22, 8, fmt.Sprintf("Month %d", j), "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Arial", "", 10)
+	for row := 1; row <= 15; row++ {
+		pdf.SetX(15)
+		for j := 1; j <= 12; j++ {
+			pdf.CellFormat(22, 8, fmt.Sprintf("%d", row*j*17%1000), "1", 0, "R", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.TransformEnd()
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+	pdf.MultiCell(0, 6, "This page follows the rotated table.", "", "", false)
+	fileStr := example.Filename("Fpdf_AddPageWithOptions")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_AddPageWithOptions.pdf
+}
+
+// TestSetPageTransition verifies that transitions and display durations are
+// set for the current page and can be removed.
+func TestSetPageTransition(t *testing.T) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetPageTransition("Wipe", 1.5, gofpdf.TransitionOptions{Direction: 180})
+	pdf.SetPageDisplayDuration(3)
+	pdf.AddPage()
+	pdf.SetPageTransition("Dissolve", 0, gofpdf.TransitionOptions{})
+	pdf.SetPageTransition("", 0, gofpdf.TransitionOptions{})
+	pdf.SetPageDisplayDuration(2)
+	pdf.AddPage()
+	pdf.SetPageTransition("Glitter", 0, gofpdf.TransitionOptions{Direction: 315})
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.3" {
+		t.Fatalf("unexpected PDF version %s", doc.version)
+	}
+	page := doc.page(1)
+	trans := pdfDict(page["/Trans"])
+	if pdfNum(page["/Dur"]) != 3 || trans["/S"] != "/Wipe" || pdfNum(trans["/D"]) != 1.5 || trans["/Di"] != "180" {
+		t.Fatalf("unexpected duration %s or transition %v", page["/Dur"], trans)
+	}
+	page = doc.page(2)
+	if _, ok := page["/Trans"]; ok || pdfNum(page["/Dur"]) != 2 {
+		t.Fatalf("removed transition present or duration %s not set", page["/Dur"])
+	}
+	trans = pdfDict(doc.page(3)["/Trans"])
+	if trans["/S"] != "/Glitter" || trans["/Di"] != "315" {
+		t.Fatalf("unexpected transition %v", trans)
+	}
+	for _, c := range []struct {
+		styleStr  string
+		direction int
+	}{{"Wipe", 45}, {"Wipe", 360}, {"Push", -90}, {"Wipe", 315}, {"Glitter", 90}, {"Glitter", 180}} {
+		pdf = gofpdf.New("L", "mm", "A4", "")
+		pdf.AddPage()
+		pdf.SetPageTransition(c.styleStr, 0, gofpdf.TransitionOptions{Direction: c.direction})
+		if !pdf.Err() {
+			t.Fatalf("invalid direction %d of %s transition not reported", c.direction, c.styleStr)
+		}
+	}
+}
+
+// ExampleFpdf_SetPageTransition demonstrates a self-running slide show. When
+// the document is opened in full screen mode, each slide is shown for a few
+// seconds and replaced with a different transition effect.
+func ExampleFpdf_SetPageTransition() {
+	pdf := gofpdf.New("L", "mm", "A5", "")
+	pdf.SetDisplayMode("fullpage", "single")
+	type slideType struct {
+		style string
+		opts  gofpdf.TransitionOptions
+	}
+	slideList := []slideType{
+		{"R", gofpdf.TransitionOptions{}},
+		{"Split", gofpdf.TransitionOptions{Dimension: "V", Motion: "O"}},
+		{"Blinds", gofpdf.TransitionOptions{Dimension: "H"}},
+		{"Box", gofpdf.TransitionOptions{Motion: "I"}},
+		{"Wipe", gofpdf.TransitionOptions{Direction: 90}},
+		{"Dissolve", gofpdf.TransitionOptions{}},
+		{"Glitter", gofpdf.TransitionOptions{Direction: 315}},
+		{"Fly", gofpdf.TransitionOptions{Direction: 0, Scale: 0.5}},
+		{"Push", gofpdf.TransitionOptions{Direction: 270}},
+		{"Cover", gofpdf.TransitionOptions{Direction: 180}},
+		{"Uncover", gofpdf.TransitionOptions{Direction: 0}},
+		{"Fade", gofpdf.TransitionOptions{}},
+	}
+	for j, slide := range slideList {
+		pdf.AddPage()
+		pdf.SetPageTransition(slide.style, 1, slide.opts)
+		pdf.SetPageDisplayDuration(3)
+		pdf.SetFillColor(40+j*15, 80, 200-j*10)
+		wd, ht := pdf.GetPageSize()
+		pdf.Rect(0, 0, wd, ht, "F")
+		pdf.SetTextColor(255, 255, 255)
+		pdf.SetFont("Helvetica", "B", 36)
+		pdf.SetXY(0, ht/2-10)
+		pdf.CellFormat(wd, 20, slide.style, "", 0, "C", false, 0, "")
+	}
+	fileStr := example.Filename("Fpdf_SetPageTransition")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetPageTransition.pdf
+}
+
+// TestSetViewerPreferences verifies the catalog entries written for viewer
+// preferences and the page mode.
+func TestSetViewerPreferences(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetViewerPreferences(gofpdf.ViewerPrefs{
+		HideToolbar:     true,
+		DisplayDocTitle: true,
+		PageMode:        "UseThumbs",
+		Direction:       "R2L",
+		PrintScaling:    "None",
+		Duplex:          "DuplexFlipLongEdge",
+		PrintPageRange:  []int{1, 2, 5, 5},
+		NumCopies:       2,
+	})
+	pdf.AddPage()
+	pdf.Bookmark("Start", 0, 0)
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.7" {
+		t.Fatalf("expected PDF version 1.7, got %s", doc.version)
+	}
+	catalog := doc.catalog()
+	prefs := pdfDict(catalog["/ViewerPreferences"])
+	// The page mode overrides the bookmark pane
+	if catalog["/PageMode"] != "/UseThumbs" {
+		t.Fatalf("unexpected page mode %s", catalog["/PageMode"])
+	}
+	for key, valStr := range map[string]string{"/HideToolbar": "true", "/DisplayDocTitle": "true",
+		"/Direction": "/R2L", "/PrintScaling": "/None", "/Duplex": "/DuplexFlipLongEdge", "/NumCopies": "2"} {
+		if prefs[key] != valStr {
+			t.Fatalf("expected %s %s, got %q", key, valStr, prefs[key])
+		}
+	}
+	// Page ranges are zero-based in the document
+	if rng := pdfNums(prefs["/PrintPageRange"]); fmt.Sprint(rng) != "[0 1 4 4]" {
+		t.Fatalf("unexpected print page range %v", rng)
+	}
+	// The layer pane takes precedence over bookmarks unless a page mode is
+	// specified
+	for _, mode := range []string{"", "UseOC", "FullScreen"} {
+		pdf = gofpdf.New("P", "mm", "A4", "")
+		pdf.SetViewerPreferences(gofpdf.ViewerPrefs{PageMode: mode})
+		pdf.AddLayer("Layer", true)
+		pdf.OpenLayerPane()
+		pdf.AddPage()
+		pdf.Bookmark("Start", 0, 0)
+		if mode == "" {
+			mode = "UseOC"
+		}
+		if modeStr := pdfParse(t, pdf).catalog()["/PageMode"]; modeStr != "/"+mode {
+			t.Fatalf("expected page mode %q, got %q", mode, modeStr)
+		}
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetViewerPreferences(gofpdf.ViewerPrefs{PageMode: "UseOC"})
+	pdf.AddPage()
+	if version := pdfParse(t, pdf).version; version != "1.5" {
+		t.Fatalf("expected PDF version 1.5 for layer pane page mode, got %s", version)
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetViewerPreferences(gofpdf.ViewerPrefs{Duplex: "Sometimes"})
+	if !pdf.Err() {
+		t.Fatalf("invalid duplex mode not reported")
+	}
+}
+
+// ExampleFpdf_SetViewerPreferences demonstrates a document that opens with its
+// bookmarks panel in a centered window, shows its title rather than its file
+// name, and asks to be printed double-sided without scaling.
+func ExampleFpdf_SetViewerPreferences() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Print shop proof", false)
+	pdf.SetViewerPreferences(gofpdf.ViewerPrefs{
+		CenterWindow:    true,
+		FitWindow:       true,
+		DisplayDocTitle: true,
+		PageMode:        "UseOutlines",
+		PrintScaling:    "None",
+		Duplex:          "DuplexFlipLongEdge",
+		NumCopies:       2,
+	})
+	pdf.SetFont("Arial", "", 14)
+	for j := 1; j <= 4; j++ {
+		pdf.AddPage()
+		if j%2 == 1 {
+			pdf.Bookmark(fmt.Sprintf("Sheet %d", (j+1)/2), 0, 0)
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("Sheet %d, side %d", (j+1)/2, 2-j%2), "", 1, "C", false, 0, "")
+	}
+	fileStr := example.Filename("Fpdf_SetViewerPreferences")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetViewerPreferences.pdf
+}
+
+// TestNamedDestination verifies named destinations, links to them and remote
+// and launch link actions.
+func TestNamedDestination(t *testing.T) {
+	pdf := gofpdf.New("P", "pt", "Letter", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.CellFormat(100, 20, "Intro", "", 1, "", false, pdf.AddNamedLink("intro"), "")
+	pdf.CellFormat(100, 20, "Figure", "", 1, "", false, pdf.AddNamedLink("figure"), "")
+	pdf.CellFormat(100, 20, "Remote", "", 1, "", false, pdf.AddRemoteLink("vol2.pdf", "ch1"), "")
+	pdf.CellFormat(100, 20, "Launch", "", 1, "", false, pdf.AddLaunchLink("readme.txt"), "")
+	pdf.AddPage()
+	pdf.AddPage()
+	pdf.AddNamedDestination("intro", -1, 0, 100, gofpdf.FitXYZ)
+	pdf.AddNamedDestination("whole", 3, 0, 0, gofpdf.FitPage)
+	pdf.AddNamedDestinationRect("figure", 3, 50, 100, 200, 150)
+	pdf.AddNamedDestination("bad", 9, 0, 0, gofpdf.FitPage)
+	if !pdf.Err() {
+		t.Fatalf("invalid destination page not reported")
+	}
+	pdf.ClearError()
+	// Page 3 becomes page 2
+	pdf.DeletePage(2)
+	doc := pdfParse(t, pdf)
+	annots := pdfItems(doc.page(1)["/Annots"])
+	if len(annots) != 4 {
+		t.Fatalf("expected 4 links, got %d", len(annots))
+	}
+	if pdfDict(annots[0])["/Dest"] != "(intro)" || pdfDict(annots[1])["/Dest"] != "(figure)" {
+		t.Fatalf("links do not refer to named destinations")
+	}
+	action := pdfDict(pdfDict(annots[2])["/A"])
+	if action["/S"] != "/GoToR" || action["/F"] != "(vol2.pdf)" || action["/D"] != "(ch1)" {
+		t.Fatalf("unexpected remote link action %v", action)
+	}
+	action = pdfDict(pdfDict(annots[3])["/A"])
+	if action["/S"] != "/Launch" || action["/F"] != "(readme.txt)" {
+		t.Fatalf("unexpected launch link action %v", action)
+	}
+	// Destinations are sorted by name and follow their page
+	names := pdfItems(doc.dict(pdfDict(doc.catalog()["/Names"])["/Dests"])["/Names"])
+	if len(names) != 6 || names[0] != "(figure)" || names[2] != "(intro)" || names[4] != "(whole)" {
+		t.Fatalf("unexpected destination names %v", names)
+	}
+	for j, expect := range []string{"/FitR 50 542 250 692", "/XYZ 0 692 null", "/Fit"} {
+		dest := pdfItems(names[2*j+1])
+		if doc.pageNum(dest[0]) != 2 {
+			t.Fatalf("destination %s not on page 2", names[2*j])
+		}
+		got := dest[1]
+		for _, v := range dest[2:] {
+			if v == "null" {
+				got += " null"
+			} else {
+				got += fmt.Sprintf(" %g", pdfNum(v))
+			}
+		}
+		if got != expect {
+			t.Fatalf("destination %s: expected %s, got %s", names[2*j], expect, got)
+		}
+	}
+}
+
+// ExampleFpdf_AddNamedDestination demonstrates named destinations along with
+// links to them, to a named destination in another document, and to a file
+// that is opened by its associated application.
+func ExampleFpdf_AddNamedDestination() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, "Volume 1", "", 1, "", false, 0, "")
+	pdf.SetFont("Arial", "U", 12)
+	pdf.SetTextColor(0, 0, 200)
+	pdf.WriteLinkID(6, "Installation", pdf.AddNamedLink("install"))
+	pdf.Ln(8)
+	pdf.WriteLinkID(6, "Wiring diagram (zoomed)", pdf.AddNamedLink("diagram"))
+	pdf.Ln(8)
+	pdf.WriteLinkID(6, "Volume 2, chapter 1", pdf.AddRemoteLink("volume2.pdf", "chapter-1"))
+	pdf.Ln(8)
+	pdf.WriteLinkID(6, "Release notes", pdf.AddLaunchLink("release-notes.txt"))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.AddNamedDestination("install", -1, 0, -1, gofpdf.FitWidth)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 10, "Installation", "", 1, "", false, 0, "")
+	pdf.SetFont("Arial", "", 12)
+	pdf.MultiCell(0, 6, "Mount the unit on a flat surface and connect it as shown "+
+		"in the wiring diagram below.", "", "", false)
+	pdf.Ln(10)
+	x, y := pdf.GetX(), pdf.GetY()
+	pdf.AddNamedDestinationRect("diagram", -1, x-5, y-5, 110, 70)
+	pdf.Rect(x, y, 100, 60, "D")
+	pdf.Line(x+10, y+30, x+90, y+30)
+	pdf.Circle(x+10, y+30, 4, "D")
+	pdf.Circle(x+90, y+30, 4, "D")
+	fileStr := example.Filename("Fpdf_AddNamedDestination")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_AddNamedDestination.pdf
+}
+
+// TestBookmarkWithOptions verifies the outline entries written for bookmarks
+// with options.
+func TestBookmarkWithOptions(t *testing.T) {
+	pdf := gofpdf.New("P", "pt", "Letter", "")
+	for j := 0; j < 3; j++ {
+		pdf.AddPage()
+	}
+	pdf.BookmarkWithOptions("Part", 0, gofpdf.BookmarkOptions{Page: 1, Y: 0, Open: true,
+		Color: &gofpdf.RGBType{R: 255, G: 0, B: 0}, Bold: true})
+	pdf.BookmarkWithOptions("Chapter", 1, gofpdf.BookmarkOptions{Page: 2, Y: 100, Fit: gofpdf.FitWidth, Italic: true})
+	pdf.Bookmark("Section", 2, 0)
+	pdf.Bookmark("Section", 2, 0)
+	pdf.BookmarkWithOptions("Web", 1, gofpdf.BookmarkOptions{URI: "https://example.com"})
+	pdf.BookmarkWithOptions("Script", 0, gofpdf.BookmarkOptions{JavaScript: "app.alert('hi');"})
+	pdf.BookmarkWithOptions("Bad", 0, gofpdf.BookmarkOptions{Page: 7})
+	if !pdf.Err() {
+		t.Fatalf("invalid bookmark page not reported")
+	}
+	pdf.ClearError()
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.4" {
+		t.Fatalf("expected PDF version 1.4 for styled bookmarks, got %s", doc.version)
+	}
+	outline := func(title string) map[string]string {
+		list := doc.find("/Title", "("+title+")")
+		if len(list) != 1 {
+			t.Fatalf("expected one bookmark titled %s, got %d", title, len(list))
+		}
+		return list[0]
+	}
+	// An open entry counts its visible descendants; a closed entry negates
+	// the count of its children
+	part := outline("Part")
+	dest := pdfItems(part["/Dest"])
+	if doc.pageNum(dest[0]) != 1 || dest[1] != "/XYZ" || part["/F"] != "2" || part["/Count"] != "2" ||
+		fmt.Sprint(pdfNums(part["/C"])) != "[1 0 0]" {
+		t.Fatalf("unexpected bookmark %v", part)
+	}
+	chapter := outline("Chapter")
+	dest = pdfItems(chapter["/Dest"])
+	if doc.pageNum(dest[0]) != 2 || dest[1] != "/FitH" || pdfNum(dest[2]) != 692 ||
+		chapter["/F"] != "1" || chapter["/Count"] != "-2" {
+		t.Fatalf("unexpected bookmark %v", chapter)
+	}
+	action := pdfDict(outline("Web")["/A"])
+	if action["/S"] != "/URI" || action["/URI"] != "(https://example.com)" {
+		t.Fatalf("unexpected bookmark action %v", action)
+	}
+	action = pdfDict(outline("Script")["/A"])
+	if action["/S"] != "/JavaScript" || action["/JS"] != `(app.alert\('hi'\);)` {
+		t.Fatalf("unexpected bookmark action %v", action)
+	}
+}
+
+// ExampleFpdf_BookmarkWithOptions demonstrates colored and styled bookmarks,
+// some of them initially expanded, that are added after the pages they refer
+// to.
+func ExampleFpdf_BookmarkWithOptions() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 14)
+	chapterList := []string{"Getting started", "Configuration", "Troubleshooting"}
+	var pageList []int
+	for _, chapter := range chapterList {
+		pdf.AddPage()
+		pageList = append(pageList, pdf.PageNo())
+		pdf.CellFormat(0, 10, chapter, "", 1, "", false, 0, "")
+		pdf.SetY(150)
+		pdf.CellFormat(0, 10, chapter+" in depth", "", 1, "", false, 0, "")
+	}
+	// Bookmarks are added once all pages exist
+	red := gofpdf.RGBType{R: 180, G: 0, B: 0}
+	for j, chapter := range chapterList {
+		pdf.BookmarkWithOptions(chapter, 0, gofpdf.BookmarkOptions{
+			Page: pageList[j], Y: 0, Fit: gofpdf.FitPage, Bold: true, Open: j == 0})
+		pdf.BookmarkWithOptions("Details", 1, gofpdf.BookmarkOptions{
+			Page: pageList[j], Y: 150, Fit: gofpdf.FitWidth, Italic: true})
+	}
+	pdf.BookmarkWithOptions("Online help", 0, gofpdf.BookmarkOptions{
+		URI: "https://github.com/jung-kurt/gofpdf", Color: &red})
+	fileStr := example.Filename("Fpdf_BookmarkWithOptions")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_BookmarkWithOptions.pdf
+}
+
+// TestAddAnnotation verifies the annotation objects and page references
+// written for markup annotations.
+func TestAddAnnotation(t *testing.T) {
+	pdf := gofpdf.New("P", "pt", "Letter", "")
+	pdf.AddPage()
+	link := pdf.AddLink()
+	pdf.SetLink(link, 0, -1)
+	pdf.Link(10, 10, 20, 20, link)
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "Text", X: 100, Y: 100, Wd: 20, Ht: 20,
+		Contents: "Check", Author: "Reviewer", Name: "Comment", Open: true,
+		Color: &gofpdf.RGBType{R: 255, G: 255, B: 0}})
+	pdf.AddPage()
+	pdf.AddAnnotation(2, gofpdf.Annotation{Type: "Highlight", X: 100, Y: 200, Wd: 50, Ht: 10,
+		Opacity: 0.5, Popup: true})
+	pdf.AddAnnotation(2, gofpdf.Annotation{Type: "Line", LineWidth: 1,
+		Points:      []gofpdf.PointType{{X: 100, Y: 300}, {X: 200, Y: 300}},
+		LineEndings: [2]string{"", "ClosedArrow"}})
+	pdf.AddAnnotation(2, gofpdf.Annotation{Type: "Sticky"})
+	if !pdf.Err() {
+		t.Fatalf("invalid annotation type not reported")
+	}
+	pdf.ClearError()
+	pdf.AddAnnotation(3, gofpdf.Annotation{Type: "Text"})
+	if !pdf.Err() {
+		t.Fatalf("invalid annotation page not reported")
+	}
+	pdf.ClearError()
+	// The annotations follow the second page to the front
+	pdf.MovePage(2, 1)
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.4" {
+		t.Fatalf("expected PDF version 1.4 for annotation opacity, got %s", doc.version)
+	}
+	annots := pdfItems(doc.page(1)["/Annots"])
+	if len(annots) != 3 {
+		t.Fatalf("expected 3 annotations on first page, got %d", len(annots))
+	}
+	highlight, popup, line := doc.dict(annots[0]), doc.dict(annots[1]), doc.dict(annots[2])
+	if highlight["/Subtype"] != "/Highlight" || doc.pageNum(highlight["/P"]) != 1 ||
+		pdfNum(highlight["/CA"]) != 0.5 || pdfRef(highlight["/Popup"]) != pdfRef(annots[1]) ||
+		fmt.Sprint(pdfNums(highlight["/Rect"])) != "[100 582 150 592]" ||
+		fmt.Sprint(pdfNums(highlight["/QuadPoints"])) != "[100 592 150 592 100 582 150 582]" {
+		t.Fatalf("unexpected highlight annotation %v", highlight)
+	}
+	if popup["/Subtype"] != "/Popup" || pdfRef(popup["/Parent"]) != pdfRef(annots[0]) || popup["/Open"] != "false" {
+		t.Fatalf("unexpected popup annotation %v", popup)
+	}
+	if line["/Subtype"] != "/Line" || fmt.Sprint(pdfNums(line["/L"])) != "[100 492 200 492]" ||
+		fmt.Sprint(pdfItems(line["/LE"])) != "[/None /ClosedArrow]" || pdfNum(pdfDict(line["/BS"])["/W"]) != 1 {
+		t.Fatalf("unexpected line annotation %v", line)
+	}
+	// The link and the note remain on the page they were added to
+	annots = pdfItems(doc.page(2)["/Annots"])
+	if len(annots) != 2 {
+		t.Fatalf("expected 2 annotations on second page, got %d", len(annots))
+	}
+	if dest := pdfItems(pdfDict(annots[0])["/Dest"]); doc.pageNum(dest[0]) != 2 {
+		t.Fatalf("link does not follow its page")
+	}
+	note := doc.dict(annots[1])
+	if note["/Subtype"] != "/Text" || doc.pageNum(note["/P"]) != 2 || note["/Name"] != "/Comment" ||
+		note["/Open"] != "true" || !strings.Contains(note["/Contents"], "\x00C\x00h\x00e\x00c\x00k") ||
+		!strings.Contains(note["/T"], "\x00R\x00e\x00v") ||
+		fmt.Sprint(pdfNums(note["/C"])) != "[1 1 0]" {
+		t.Fatalf("unexpected text annotation %v", note)
+	}
+}
+
+// ExampleFpdf_AddAnnotation demonstrates review annotations on a proof:
+// sticky notes, text markup, shapes, free text, ink and a stamp.
+func ExampleFpdf_AddAnnotation() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Times", "", 12)
+	pdf.AddPage()
+	pdf.SetXY(20, 30)
+	pdf.Write(6, "Quarterly results exceeded expectations in every region.")
+	author := "Proofreader"
+	yellow := gofpdf.RGBType{R: 255, G: 230, B: 0}
+	red := gofpdf.RGBType{R: 220, G: 0, B: 0}
+	// Highlight part of the sentence and attach a comment in a pop-up window
+	wd := pdf.GetStringWidth("Quarterly results")
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "Highlight", X: 20, Y: 30, Wd: wd, Ht: 6,
+		Color: &yellow, Author: author, Contents: "Which quarter?", Popup: true})
+	x := 20 + pdf.GetStringWidth("Quarterly results ")
+	wd = pdf.GetStringWidth("exceeded")
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "StrikeOut", X: x, Y: 30, Wd: wd, Ht: 6,
+		Color: &red, Author: author, Contents: "Replace with \"met\""})
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "Text", X: 180, Y: 28, Wd: 8, Ht: 8,
+		Color: &yellow, Author: author, Name: "Comment", Contents: "Cite the source of these figures."})
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "Square", X: 20, Y: 50, Wd: 80, Ht: 40,
+		Color: &red, Author: author, LineWidth: 0.5, Contents: "Chart goes here"})
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "Line", Color: &red, Author: author,
+		Points:      []gofpdf.PointType{{X: 140, Y: 70}, {X: 102, Y: 70}},
+		LineEndings: [2]string{"None", "OpenArrow"}, LineWidth: 0.5})
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "FreeText", X: 142, Y: 65, Wd: 50, Ht: 10,
+		Author: author, Contents: "Enlarge chart", FontSize: 10})
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "Ink", Color: &red, Author: author, LineWidth: 0.7,
+		InkList: [][]gofpdf.PointType{{{X: 20, Y: 110}, {X: 40, Y: 100}, {X: 60, Y: 115}, {X: 80, Y: 105}}}})
+	pdf.AddAnnotation(-1, gofpdf.Annotation{Type: "Stamp", X: 130, Y: 120, Wd: 60, Ht: 20,
+		Name: "Draft", Author: author, Color: &red, Opacity: 0.6})
+	fileStr := example.Filename("Fpdf_AddAnnotation")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_AddAnnotation.pdf
+}
+
+// TestSetOpenAction verifies the open action, document and page additional
+// actions and named JavaScript written to the document.
+func TestSetOpenAction(t *testing.T) {
+	pdf := gofpdf.New("P", "pt", "Letter", "")
+	pdf.SetDisplayMode("fullpage", "")
+	pdf.SetOpenAction(gofpdf.Action{Page: 2, Y: 100, Zoom: 1.5})
+	pdf.SetDocumentAction("WillPrint", gofpdf.Action{JavaScript: "logPrint();"})
+	pdf.SetDocumentAction("Closing", gofpdf.Action{JavaScript: "x"})
+	if !pdf.Err() {
+		t.Fatalf("invalid document action trigger not reported")
+	}
+	pdf.ClearError()
+	pdf.SetOpenAction(gofpdf.Action{Page: 1, URI: "https://example.com"})
+	if !pdf.Err() {
+		t.Fatalf("ambiguous action not reported")
+	}
+	pdf.ClearError()
+	pdf.AddPage()
+	pdf.SetPageAction("Open", gofpdf.Action{JavaScript: "this.resetForm();"})
+	pdf.AddPage()
+	pdf.SetPageAction("Close", gofpdf.Action{URI: "https://example.com"})
+	pdf.AddJavascript("b", "function logPrint() {}")
+	pdf.AddJavascript("a", "var n = 0;")
+	// The open action and the page actions follow their pages
+	pdf.MovePage(2, 1)
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.4" {
+		t.Fatalf("expected PDF version 1.4 for document actions, got %s", doc.version)
+	}
+	catalog := doc.catalog()
+	// The open action replaces the one of the display mode
+	action := pdfDict(catalog["/OpenAction"])
+	dest := pdfItems(action["/D"])
+	if action["/S"] != "/GoTo" || len(dest) != 5 || doc.pageNum(dest[0]) != 1 || dest[1] != "/XYZ" ||
+		fmt.Sprint(pdfNum(dest[2]), pdfNum(dest[3]), pdfNum(dest[4])) != "0 692 1.5" {
+		t.Fatalf("unexpected open action %v", action)
+	}
+	action = pdfDict(pdfDict(catalog["/AA"])["/WP"])
+	if action["/S"] != "/JavaScript" || action["/JS"] != `(logPrint\(\);)` {
+		t.Fatalf("unexpected document action %v", action)
+	}
+	action = pdfDict(pdfDict(doc.page(1)["/AA"])["/C"])
+	if action["/S"] != "/URI" || action["/URI"] != "(https://example.com)" {
+		t.Fatalf("unexpected page close action %v", action)
+	}
+	action = pdfDict(pdfDict(doc.page(2)["/AA"])["/O"])
+	if action["/S"] != "/JavaScript" || action["/JS"] != `(this.resetForm\(\);)` {
+		t.Fatalf("unexpected page open action %v", action)
+	}
+	// Named scripts are sorted by name
+	names := pdfItems(doc.dict(pdfDict(catalog["/Names"])["/JavaScript"])["/Names"])
+	if len(names) != 4 || names[0] != "(a)" || names[2] != "(b)" ||
+		pdfDict(doc.objs[pdfRef(names[1])])["/JS"] != "(var n = 0;)" {
+		t.Fatalf("unexpected named JavaScript %v", names)
+	}
+	pdf = gofpdf.New("P", "pt", "Letter", "")
+	pdf.SetOpenAction(gofpdf.Action{Page: 3})
+	pdf.AddPage()
+	pdf.Close()
+	if !pdf.Err() {
+		t.Fatalf("action referring to missing page not reported")
+	}
+}
+
+// ExampleFpdf_SetOpenAction demonstrates actions that run when the document
+// is opened, when a page is opened and when the document is printed, as used
+// by a kiosk form that resets its fields and logs print events.
+func ExampleFpdf_SetOpenAction() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 14)
+	pdf.AddJavascript("log", "function log(msg) { console.println(msg); }")
+	pdf.AddJavascript("reset", "function resetFields() { this.resetForm(); }")
+	pdf.AddPage()
+	pdf.Cell(0, 10, "Welcome")
+	pdf.SetPageAction("Open", gofpdf.Action{JavaScript: "resetFields();"})
+	pdf.AddPage()
+	pdf.Cell(0, 10, "Registration form")
+	pdf.SetPageAction("Close", gofpdf.Action{JavaScript: "log('Form page closed');"})
+	pdf.SetDocumentAction("WillPrint", gofpdf.Action{JavaScript: "log('Printing');"})
+	pdf.SetDocumentAction("DidPrint", gofpdf.Action{JavaScript: "log('Printed');"})
+	// Open the document at the form, magnified
+	pdf.SetOpenAction(gofpdf.Action{Page: 2, Zoom: 1.25})
+	fileStr := example.Filename("Fpdf_SetOpenAction")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetOpenAction.pdf
+}
+
+// TestTransformLink verifies that link areas follow the current
+// transformation and that template links are placed wherever the template
+// is used.
+func TestTransformLink(t *testing.T) {
+	pdf := gofpdf.New("P", "pt", "Letter", "")
+	pdf.AddPage()
+	pdf.TransformBegin()
+	pdf.TransformScale(200, 200, 0, 0)
+	pdf.LinkString(10, 10, 20, 10, "https://example.com/scaled")
+	pdf.TransformBegin()
+	pdf.TransformRotate(90, 20, 20)
+	pdf.LinkString(20, 20, 10, 5, "https://example.com/upright")
+	pdf.TransformEnd()
+	pdf.TransformRotate(30, 0, 0)
+	pdf.LinkString(50, 50, 40, 10, "https://example.com/rotated")
+	pdf.TransformEnd()
+	pdf.LinkString(100, 100, 10, 10, "https://example.com/plain")
+	tpl := pdf.CreateTemplateCustom(gofpdf.PointType{}, gofpdf.SizeType{Wd: 100, Ht: 100}, func(tpl *gofpdf.Tpl) {
+		tpl.LinkString(10, 20, 30, 40, "https://example.com/template")
+	})
+	b, err := tpl.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl, err = gofpdf.DeserializeTemplate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf.UseTemplateScaled(tpl, gofpdf.PointType{X: 200, Y: 300}, gofpdf.SizeType{Wd: 50, Ht: 50})
+	pdf.AddPage()
+	pdf.UseTemplate(tpl)
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.6" {
+		t.Fatalf("expected PDF version 1.6 for quadrilateral links, got %s", doc.version)
+	}
+	links := make(map[string]map[string]string)
+	for n := 1; n <= 2; n++ {
+		for _, annot := range pdfItems(doc.page(n)["/Annots"]) {
+			mp := pdfDict(annot)
+			links[fmt.Sprintf("%d %s", n, pdfDict(mp["/A"])["/URI"])] = mp
+		}
+	}
+	for key, rect := range map[string]string{
+		// Scaled by two about the top left corner of the page
+		"1 (https://example.com/scaled)": "[20 772 60 752]",
+		// Rotated by a right angle: still upright, so no quadrilateral
+		"1 (https://example.com/upright)":  "[40 772 50 752]",
+		"1 (https://example.com/plain)":    "[100 692 110 682]",
+		"1 (https://example.com/template)": "[205 482 220 462]",
+		"2 (https://example.com/template)": "[10 772 40 732]",
+	} {
+		link, ok := links[key]
+		if !ok {
+			t.Fatalf("link %s missing", key)
+		}
+		if got := fmt.Sprint(pdfNums(link["/Rect"])); got != rect {
+			t.Fatalf("link %s: expected area %s, got %s", key, rect, got)
+		}
+		if _, ok = link["/QuadPoints"]; ok {
+			t.Fatalf("link %s: unexpected quadrilateral", key)
+		}
+	}
+	if quad := pdfNums(links["1 (https://example.com/rotated)"]["/QuadPoints"]); len(quad) != 8 {
+		t.Fatalf("quadrilateral expected for rotated link")
+	}
+}
+
+// ExampleFpdf_TransformRotate_link demonstrates links on rotated text and in
+// a template that is used at several sizes. The clickable areas follow the
+// transformed content.
+func ExampleFpdf_TransformRotate_link() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "U", 14)
+	pdf.SetTextColor(0, 0, 200)
+	pdf.AddPage()
+	pdf.TransformBegin()
+	pdf.TransformRotate(35, 40, 80)
+	pdf.SetXY(40, 80)
+	pdf.CellFormat(70, 10, "Rotated link to the project", "1", 0, "C", false, 0,
+		"https://github.com/jung-kurt/gofpdf")
+	pdf.TransformEnd()
+	badge := pdf.CreateTemplateCustom(gofpdf.PointType{}, gofpdf.SizeType{Wd: 60, Ht: 20}, func(tpl *gofpdf.Tpl) {
+		tpl.SetFont("Helvetica", "", 12)
+		tpl.SetFillColor(230, 240, 255)
+		tpl.SetXY(0, 0)
+		tpl.CellFormat(60, 20, "Visit the documentation", "1", 0, "C", true, 0,
+			"https://godoc.org/github.com/jung-kurt/gofpdf")
+	})
+	pdf.UseTemplateScaled(badge, gofpdf.PointType{X: 20, Y: 140}, gofpdf.SizeType{Wd: 60, Ht: 20})
+	pdf.UseTemplateScaled(badge, gofpdf.PointType{X: 20, Y: 180}, gofpdf.SizeType{Wd: 120, Ht: 40})
+	fileStr := example.Filename("Fpdf_TransformRotate_link")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_TransformRotate_link.pdf
+}
+
+// TestUserToPage verifies the mapping of points through the current
+// transformation.
+func TestUserToPage(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-6
+	}
+	if pdf.GetTransform() != (gofpdf.TransformMatrix{A: 1, D: 1}) {
+		t.Fatalf("identity matrix expected outside transformation context")
+	}
+	pdf.TransformBegin()
+	pdf.TransformTranslate(10, 20)
+	pdf.TransformBegin()
+	pdf.TransformRotate(90, 50, 50)
+	// Rotating counter-clockwise moves a point right of the center upward
+	x, y := pdf.UserToPage(60, 50)
+	if !near(x, 60) || !near(y, 60) {
+		t.Fatalf("unexpected page position (%.4f, %.4f)", x, y)
+	}
+	x, y = pdf.PageToUser(x, y)
+	if !near(x, 60) || !near(y, 50) {
+		t.Fatalf("unexpected user position (%.4f, %.4f)", x, y)
+	}
+	pdf.TransformEnd()
+	x, y = pdf.UserToPage(60, 50)
+	if !near(x, 70) || !near(y, 70) {
+		t.Fatalf("unexpected page position (%.4f, %.4f) after TransformEnd()", x, y)
+	}
+	pdf.Transform(gofpdf.TransformMatrix{A: 1, B: 1, C: 1, D: 1})
+	pdf.PageToUser(0, 0)
+	if !pdf.Err() {
+		t.Fatalf("singular matrix not reported")
+	}
+}
+
+// ExampleFpdf_UserToPage demonstrates callouts that point to features of a
+// rotated and scaled drawing. The callout text and leader lines are drawn
+// without transformation, at the page positions of the features.
+func ExampleFpdf_UserToPage() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.AddPage()
+	type featureType struct {
+		x, y  float64
+		label string
+	}
+	features := []featureType{{60, 60, "Inlet"}, {100, 60, "Outlet"}, {80, 90, "Drain"}}
+	var calloutX, calloutY []float64
+	pdf.TransformBegin()
+	pdf.TransformRotate(25, 80, 75)
+	pdf.TransformScale(150, 150, 80, 75)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Rect(60, 60, 40, 30, "D")
+	for _, ft := range features {
+		pdf.Circle(ft.x, ft.y, 1.5, "D")
+		x, y := pdf.UserToPage(ft.x, ft.y)
+		calloutX = append(calloutX, x)
+		calloutY = append(calloutY, y)
+	}
+	pdf.TransformEnd()
+	pdf.SetDrawColor(200, 0, 0)
+	pdf.SetTextColor(200, 0, 0)
+	for j, ft := range features {
+		labelY := 150 + 10*float64(j)
+		pdf.Line(calloutX[j], calloutY[j], 150, labelY)
+		pdf.Text(152, labelY+1, ft.label)
+	}
+	fileStr := example.Filename("Fpdf_UserToPage")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_UserToPage.pdf
+}
+
+// TestDrawSVG verifies the parsing of an SVG document and the operators with
+// which its shapes are drawn
+func TestDrawSVG(t *testing.T) {
+	doc, err := gofpdf.SVGDocumentParse([]byte(`<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"
+  width="200" height="100" viewBox="0 0 100 50">
+  <defs><rect id="box" width="5" height="5" style="fill:#00f"/></defs>
+  <rect x="10" y="10" width="20" height="10" fill="red"/>
+  <g transform="translate(50 0)" stroke="rgb(0,128,0)" stroke-width="2" fill="none">
+    <path d="M0 0 H10 V10 Z"/>
+  </g>
+  <use xlink:href="#box" x="80" y="30"/>
+  <circle cx="5" cy="5" r="3" display="none"/>
+</svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Wd != 200 || doc.Ht != 100 || doc.ViewBox != [4]float64{0, 0, 100, 50} {
+		t.Fatalf("unexpected document size %.2f x %.2f, view box %v", doc.Wd, doc.Ht, doc.ViewBox)
+	}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFillColor(10, 20, 30)
+	pdf.SetXY(15, 25)
+	pdf.DrawSVG(&doc, 10, 10, 100, 0)
+	if x, y := pdf.GetXY(); x != 15 || y != 25 {
+		t.Fatalf("position not restored after drawing: %.2f, %.2f", x, y)
+	}
+	if r, g, b := pdf.GetFillColor(); r != 10 || g != 20 || b != 30 {
+		t.Fatalf("fill color not restored after drawing: %d %d %d", r, g, b)
+	}
+	ops := pdfParse(t, pdf).content(1)
+	// The drawing is clipped to its area of 100 x 50 mm at (10, 10) mm, so
+	// the red rectangle at (10, 10) in the view box is at (20, 20) mm
+	j := pdfFind(ops, 0, "re", "28.35", "813.54", "283.46", "-141.73")
+	if j < 0 || ops[j+1].op != "W" {
+		t.Fatalf("drawing not clipped to its area")
+	}
+	if j = pdfFind(ops, j, "rg", "1", "0", "0"); j < 0 || pdfFind(ops, j, "m", "56.69", "785.20") < 0 {
+		t.Fatalf("red rectangle not drawn at its position")
+	}
+	// The group is translated and its path stroked with the inherited style
+	if j = pdfFind(ops, 0, "cm", "1", "0", "0", "1", "141.73", "0"); j < 0 ||
+		pdfFind(ops, j, "RG", "0", "0.502", "0") < 0 || pdfFind(ops, j, "w", "5.67") < 0 ||
+		pdfFind(ops, j, "m", "28.35", "813.54") < 0 {
+		t.Fatalf("group not translated and stroked")
+	}
+	// The referenced rectangle is drawn with its own style and the hidden
+	// circle is not drawn
+	if pdfFind(ops, 0, "rg", "0", "0", "1") < 0 || pdfCount(ops, "c") != 0 {
+		t.Fatalf("referenced rectangle not drawn or hidden circle drawn")
+	}
+	// A nested svg element fits its view box into its viewport and clips to it
+	doc, err = gofpdf.SVGDocumentParse([]byte(`<svg width="100" height="100" viewBox="0 0 100 100">
+  <svg x="10" y="20" width="40" height="20" viewBox="0 0 10 10">
+    <rect width="10" height="10" fill="red"/>
+  </svg>
+</svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.DrawSVG(&doc, 0, 0, 100, 100)
+	ops = pdfParse(t, pdf).content(1)
+	if j = pdfFind(ops, 0, "re", "28.35", "785.20", "113.39", "-56.69"); j < 0 || ops[j+1].op != "W" ||
+		pdfFind(ops, j, "cm", "2", "0", "0", "2", "56.69", "-898.58") < 0 ||
+		pdfFind(ops, j, "m", "0", "841.89") < 0 {
+		t.Fatalf("nested svg element not fitted into its viewport")
+	}
+	// The opacity of a group applies to the group as a whole, and percentages
+	// in gradients refer to the viewport in which they are used
+	doc, err = gofpdf.SVGDocumentParse([]byte(`<svg width="100" height="100" viewBox="0 0 100 100">
+  <linearGradient id="lg" gradientUnits="userSpaceOnUse" x1="0" x2="100%">
+    <stop offset="0" stop-color="red"/><stop offset="1" stop-color="blue"/>
+  </linearGradient>
+  <g opacity="0.5">
+    <rect width="50" height="50" fill="red"/>
+    <rect x="25" y="25" width="50" height="50" fill="blue" fill-opacity="0.8"/>
+  </g>
+  <svg width="50" height="50" viewBox="0 0 10 10">
+    <rect width="10" height="10" fill="url(#lg)"/>
+  </svg>
+</svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetAlpha(0.8, "Normal")
+	pdf.DrawSVG(&doc, 0, 0, 100, 100)
+	if alpha, _ := pdf.GetAlpha(); alpha != 0.8 {
+		t.Fatalf("alpha not restored after drawing: %.2f", alpha)
+	}
+	pdfDoc := pdfParse(t, pdf)
+	ops = pdfDoc.content(1)
+	if j = pdfFind(ops, 0, "Do"); j < 0 || pdfCount(ops, "Do") != 1 {
+		t.Fatalf("expecting group to be drawn once")
+	}
+	if gs := pdfDoc.dict(pdfDoc.resource(1, "/ExtGState", ops[j-1].args[0])); pdfNum(gs["/ca"]) != 0.4 {
+		t.Fatalf("group not drawn with its opacity, got %s", gs["/ca"])
+	}
+	groupOps := pdfOps(pdfDoc.stream(pdfDoc.resource(1, "/XObject", ops[j].args[0])))
+	if pdfCount(groupOps, "f") != 2 || pdfCount(groupOps, "gs") != 1 {
+		t.Fatalf("expecting group content with only the alpha of its second rectangle")
+	}
+	if gr := pdfDoc.find("/ShadingType", "2"); len(gr) != 1 || fmt.Sprint(pdfNums(gr[0]["/Coords"])) != "[0 0 10 0]" {
+		t.Fatalf("gradient percentage not resolved against its viewport")
+	}
+	_, err = gofpdf.SVGDocumentParse([]byte(`<svg width="10"><rect></svg>`))
+	if err == nil {
+		t.Fatalf("malformed document not reported")
+	}
+}
+
+// ExampleFpdf_DrawSVG demonstrates the rendering of SVG images with native
+// PDF vector operators. Unlike images drawn with SVGBasicWrite(), they keep
+// their colors, gradients, transparency and line styles.
+func ExampleFpdf_DrawSVG() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	scene, err := gofpdf.SVGDocumentParse([]byte(`<svg xmlns="http://www.w3.org/2000/svg"
+  viewBox="0 0 400 300">
+  <defs>
+    <linearGradient id="sky" x1="0" y1="0" x2="0" y2="1">
+      <stop offset="0" stop-color="#3a7bd5"/>
+      <stop offset="1" stop-color="#d4ecff"/>
+    </linearGradient>
+    <radialGradient id="sun" fx="35%" fy="35%">
+      <stop offset="0" stop-color="#fff7b0"/>
+      <stop offset="1" stop-color="orange"/>
+    </radialGradient>
+    <g id="tree">
+      <rect x="-4" y="0" width="8" height="30" fill="saddlebrown"/>
+      <circle cx="0" cy="-10" r="22" fill="forestgreen" stroke="darkgreen" stroke-width="2"/>
+    </g>
+  </defs>
+  <rect width="400" height="300" fill="url(#sky)"/>
+  <circle cx="310" cy="70" r="40" fill="url(#sun)"/>
+  <path d="M60 210 A110 110 0 0 1 280 210" fill="none" stroke="#e74c3c" stroke-width="8"
+    opacity="0.5"/>
+  <path d="M0 220 Q100 150 200 210 T400 200 V300 H0 Z" fill="#6ab04c"/>
+  <g opacity="0.6" fill="white">
+    <ellipse cx="90" cy="60" rx="45" ry="18"/>
+    <ellipse cx="130" cy="70" rx="35" ry="14"/>
+  </g>
+  <use href="#tree" x="80" y="215"/>
+  <use href="#tree" x="130" y="205" transform="rotate(-4 130 235)"/>
+  <polyline points="20,280 120,260 220,275 380,250" fill="none" stroke="#8d6e63"
+    stroke-width="6" stroke-linecap="round" stroke-dasharray="12 8"/>
+  <rect x="10" y="10" width="380" height="280" rx="16" fill="none" stroke="#333"
+    stroke-width="4" stroke-linejoin="round"/>
+</svg>`))
+	if err == nil {
+		pdf.DrawSVG(&scene, 15, 20, 180, 0)
+		pdf.DrawSVG(&scene, 15, 170, 60, 0)
+		pdf.DrawSVG(&scene, 80, 170, 120, 40)
+	}
+	y := 230.0
+	for _, name := range []string{"mit.svg", "doc.svg"} {
+		var badge gofpdf.SVGDocument
+		badge, err = gofpdf.SVGDocumentFileParse(example.ImageFile(name))
+		if err == nil {
+			pdf.DrawSVG(&badge, 15, y, 0, 0)
+			pdf.DrawSVG(&badge, 60, y, 0, 15)
+		}
+		y += 25
+	}
+	if err != nil {
+		pdf.SetError(err)
+	}
+	fileStr := example.Filename("Fpdf_DrawSVG")
+	err = pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_DrawSVG.pdf
+}
+
+// TestSetFillGradient verifies the objects written for gradients with color
+// stops and transparent stops
+func TestSetFillGradient(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.SetFillGradient(gofpdf.Gradient{X1: 10, Y1: 10, X2: 110, Y2: 10, ExtendEnd: true,
+		Stops: []gofpdf.GradientStop{
+			{Offset: 0.5, R: 0, G: 255, B: 0, Alpha: 1},
+			{Offset: 0, R: 255, G: 0, B: 0, Alpha: 1},
+			{Offset: 1, R: 0, G: 0, B: 255, Alpha: 1},
+		}})
+	pdf.Rect(10, 10, 100, 20, "F")
+	if r, g, b := pdf.GetFillColor(); r != 255 || g != 0 || b != 0 {
+		t.Fatalf("unexpected fill color %d %d %d", r, g, b)
+	}
+	pdf.SetFillGradient(gofpdf.Gradient{Radial: true, X1: 50, Y1: 60, X2: 50, Y2: 60, R2: 20,
+		Stops: []gofpdf.GradientStop{
+			{Offset: 0, R: 255, G: 255, B: 255, Alpha: 1},
+			{Offset: 1, R: 255, G: 255, B: 255, Alpha: 0},
+		}})
+	pdf.Circle(50, 60, 20, "F")
+	pdf.Line(10, 85, 100, 85)
+	pdf.MoveTo(10, 40)
+	pdf.LineTo(30, 40)
+	pdf.LineTo(20, 50)
+	pdf.ClosePath()
+	pdf.DrawPath("F")
+	pdf.SetFillColor(200, 200, 200)
+	pdf.SetTextGradient(gofpdf.Gradient{X1: 10, Y1: 90, X2: 60, Y2: 90,
+		Stops: []gofpdf.GradientStop{{R: 255, Alpha: 0.5}, {Offset: 1, B: 255, Alpha: 1}}})
+	pdf.Text(10, 90, "Gradient")
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.4" {
+		t.Fatalf("unexpected version %s", doc.version)
+	}
+	// The stops of the axial gradient are sorted by offset
+	axial := doc.find("/ShadingType", "2")
+	if len(axial) == 0 || fmt.Sprint(pdfNums(axial[0]["/Coords"])) != "[10 10 110 10]" ||
+		axial[0]["/Extend"] != "[false true]" {
+		t.Fatalf("axial gradient not defined")
+	}
+	if fn := doc.dict(axial[0]["/Function"]); fn["/FunctionType"] != "3" ||
+		fmt.Sprint(pdfNums(fn["/Bounds"])) != "[0.5]" {
+		t.Fatalf("stops of axial gradient not sorted")
+	}
+	radial := doc.find("/ShadingType", "3")
+	if len(radial) == 0 || fmt.Sprint(pdfNums(radial[0]["/Coords"])) != "[50 60 0 50 60 20]" {
+		t.Fatalf("radial gradient not defined")
+	}
+	// Each gradient with transparent stops has a soft mask that applies only
+	// to the shapes it fills and to text
+	ops := doc.content(1)
+	j := pdfFind(ops, 0, "scn", "/P2")
+	if j = pdfFind(ops, j, "gs", "/SM1"); j < 0 {
+		t.Fatalf("mask of fill gradient not applied")
+	}
+	if k := pdfFind(ops, j, "Q"); k < 0 || pdfFind(ops, j, "f") > k || pdfFind(ops, j, "S") < k {
+		t.Fatalf("mask of fill gradient applied to stroked line")
+	}
+	if pdfCount(ops, "f") != 3 {
+		t.Fatalf("expecting 3 filled shapes, got %d", pdfCount(ops, "f"))
+	}
+	if j = pdfFind(ops, 0, "gs", "/SM2"); j < 0 || ops[j+3].op != "BT" {
+		t.Fatalf("mask of text gradient not applied to text")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFillGradient(gofpdf.Gradient{})
+	if !pdf.Err() {
+		t.Fatalf("gradient without stops not reported")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFillGradient(gofpdf.Gradient{Stops: []gofpdf.GradientStop{{Alpha: 2}}})
+	if !pdf.Err() {
+		t.Fatalf("invalid alpha value not reported")
+	}
+}
+
+// TestSetFillGradientTemplate verifies that a fill gradient within a
+// template, whose resources cannot hold it, halts the document
+func TestSetFillGradientTemplate(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.CreateTemplate(func(tpl *gofpdf.Tpl) {
+		tpl.Rect(10, 10, 20, 20, "D")
+	})
+	if pdf.Err() {
+		t.Fatalf("unexpected error %s", pdf.Error())
+	}
+	pdf.CreateTemplate(func(tpl *gofpdf.Tpl) {
+		tpl.SetFillGradient(gofpdf.Gradient{X2: 20,
+			Stops: []gofpdf.GradientStop{{Alpha: 1}, {Offset: 1, R: 255, Alpha: 0}}})
+		tpl.Rect(10, 10, 20, 20, "F")
+	})
+	if !pdf.Err() {
+		t.Fatal("expected error for fill gradient within template")
+	}
+}
+
+// ExampleFpdf_SetFillGradient demonstrates gradients with multiple color
+// stops and transparency used to fill shapes and text.
+func ExampleFpdf_SetFillGradient() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	// Chart bars share a gradient that spans the full value range
+	heat := gofpdf.Gradient{X1: 0, Y1: 110, X2: 0, Y2: 20, Stops: []gofpdf.GradientStop{
+		{Offset: 0, R: 40, G: 110, B: 220, Alpha: 1},
+		{Offset: 0.4, R: 60, G: 200, B: 120, Alpha: 1},
+		{Offset: 0.7, R: 250, G: 210, B: 60, Alpha: 1},
+		{Offset: 1, R: 220, G: 50, B: 40, Alpha: 1},
+	}}
+	pdf.SetFillGradient(heat)
+	for j, v := range []float64{35, 60, 90, 72, 48, 80} {
+		pdf.Rect(20+float64(j)*14, 110-v, 10, v, "F")
+	}
+	pdf.SetDrawColor(80, 80, 80)
+	pdf.Line(15, 110, 105, 110)
+	// Button with a glossy highlight
+	pdf.SetFillGradient(gofpdf.Gradient{X1: 0, Y1: 25, X2: 0, Y2: 45, Stops: []gofpdf.GradientStop{
+		{Offset: 0, R: 120, G: 170, B: 250, Alpha: 1},
+		{Offset: 0.5, R: 50, G: 110, B: 220, Alpha: 1},
+		{Offset: 0.5, R: 30, G: 90, B: 200, Alpha: 1},
+		{Offset: 1, R: 40, G: 100, B: 210, Alpha: 1},
+	}})
+	pdf.RoundedRect(120, 25, 70, 20, 5, "1234", "F")
+	// Spotlight that fades into the background
+	pdf.SetFillColor(30, 30, 50)
+	pdf.Rect(120, 55, 70, 55, "F")
+	pdf.SetFillGradient(gofpdf.Gradient{Radial: true, X1: 150, Y1: 75, X2: 155, Y2: 82, R2: 30,
+		Stops: []gofpdf.GradientStop{
+			{Offset: 0, R: 255, G: 250, B: 200, Alpha: 1},
+			{Offset: 1, R: 255, G: 220, B: 120, Alpha: 0},
+		}})
+	pdf.Circle(155, 82, 30, "F")
+	pdf.SetFillColor(255, 255, 255)
+	// Text with a gradient
+	pdf.SetFont("Helvetica", "B", 48)
+	pdf.SetTextGradient(gofpdf.Gradient{X1: 20, Y1: 0, X2: 190, Y2: 0, Stops: []gofpdf.GradientStop{
+		{Offset: 0, R: 150, G: 0, B: 200, Alpha: 1},
+		{Offset: 0.5, R: 230, G: 40, B: 120, Alpha: 1},
+		{Offset: 1, R: 250, G: 150, B: 0, Alpha: 0.3},
+	}})
+	pdf.Text(20, 150, "Gradient text")
+	// Polygon with a gradient that stops short of the shape
+	pdf.SetFillGradient(gofpdf.Gradient{X1: 40, Y1: 170, X2: 90, Y2: 220, Stops: []gofpdf.GradientStop{
+		{Offset: 0, R: 0, G: 150, B: 150, Alpha: 1},
+		{Offset: 1, R: 200, G: 240, B: 240, Alpha: 1},
+	}})
+	pdf.Polygon([]gofpdf.PointType{{X: 65, Y: 165}, {X: 95, Y: 195}, {X: 65, Y: 225}, {X: 35, Y: 195}}, "FD")
+	fileStr := example.Filename("Fpdf_SetFillGradient")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetFillGradient.pdf
+}
+
+// TestAddTilingPattern verifies the pattern objects written for tiling
+// patterns and the operators that select them
+func TestAddTilingPattern(t *testing.T) {
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.AddPage()
+	checker := pdf.AddTilingPattern(20, 20, 40, 30, func(tpl *gofpdf.Tpl) {
+		tpl.SetFillColor(255, 0, 0)
+		tpl.Rect(0, 0, 20, 20, "F")
+	})
+	hatch := pdf.AddHatchPattern("vertical", 10, 2, 0, 0, 255)
+	pdf.SetFillPattern(checker)
+	pdf.Rect(10, 10, 100, 100, "F")
+	pdf.SetDrawPattern(hatch)
+	pdf.SetLineWidth(8)
+	pdf.Line(10, 200, 200, 200)
+	doc := pdfParse(t, pdf)
+	ops := doc.content(1)
+	if j := pdfFind(ops, 0, "scn", "/P1"); j < 0 || pdfFind(ops, j, "re") < 0 {
+		t.Fatalf("fill pattern not selected")
+	}
+	if j := pdfFind(ops, 0, "SCN", "/P2"); j < 0 || pdfFind(ops, j, "S") < 0 {
+		t.Fatalf("stroke pattern not selected")
+	}
+	// The pattern cell is anchored to the top left corner of the page
+	list := doc.find("/PatternType", "1")
+	if len(list) != 2 {
+		t.Fatalf("expecting 2 tiling patterns, got %d", len(list))
+	}
+	if pdfNum(list[0]["/XStep"]) != 40 || pdfNum(list[0]["/YStep"]) != 30 ||
+		fmt.Sprint(pdfNums(list[0]["/BBox"])) != "[0 0 20 20]" ||
+		fmt.Sprint(pdfNums(list[0]["/Matrix"])) != "[1 0 0 1 0 821.89]" {
+		t.Fatalf("unexpected cell of tiling pattern")
+	}
+	if pdfNum(list[1]["/XStep"]) != 10 || pdfNum(list[1]["/YStep"]) != 10 {
+		t.Fatalf("unexpected cell of hatch pattern")
+	}
+	pdf.SetFillPattern(7)
+	if !pdf.Err() {
+		t.Fatalf("invalid pattern not reported")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddHatchPattern("zigzag", 1, 1, 0, 0, 0)
+	if !pdf.Err() {
+		t.Fatalf("invalid hatch style not reported")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	hatch = pdf.AddHatchPattern("dots", 2, 1, 0, 0, 0)
+	pdf.CreateTemplate(func(tpl *gofpdf.Tpl) {
+		tpl.SetFillPattern(hatch)
+		tpl.Rect(0, 0, 20, 20, "F")
+	})
+	if pdf.Output(ioutil.Discard) == nil {
+		t.Fatalf("pattern within template not reported")
+	}
+}
+
+// ExampleFpdf_AddTilingPattern demonstrates hatched and patterned fills of
+// the kind used in charts printed in grayscale and in map legends.
+func ExampleFpdf_AddTilingPattern() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.AddPage()
+	styles := []string{"horizontal", "vertical", "grid", "diagonal", "backdiagonal",
+		"crosshatch", "dots"}
+	for j, style := range styles {
+		x := 15 + float64(j%4)*47
+		y := 20 + float64(j/4)*45
+		pdf.SetFillPattern(pdf.AddHatchPattern(style, 2.5, 0.4, 40, 40, 40))
+		pdf.Rect(x, y, 38, 30, "FD")
+		pdf.Text(x, y+35, style)
+	}
+	// A custom cell with brick courses for a map legend
+	brick := pdf.AddTilingPattern(12, 6, 12, 6, func(tpl *gofpdf.Tpl) {
+		tpl.SetFillColor(190, 90, 60)
+		tpl.Rect(0, 0, 12, 6, "F")
+		tpl.SetDrawColor(235, 225, 210)
+		tpl.SetLineWidth(0.6)
+		tpl.Line(0, 0, 12, 0)
+		tpl.Line(0, 3, 12, 3)
+		tpl.Line(3, 0, 3, 3)
+		tpl.Line(9, 3, 9, 6)
+	})
+	pdf.SetFillPattern(brick)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.RoundedRect(156, 65, 38, 30, 4, "1234", "FD")
+	pdf.Text(156, 100, "custom")
+	// Patterned lines and a rotated hatch
+	pdf.SetDrawPattern(pdf.AddHatchPattern("dots", 1.5, 0.8, 0, 100, 180))
+	pdf.SetLineWidth(6)
+	pdf.Line(15, 125, 195, 125)
+	pdf.SetLineWidth(0.2)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.TransformBegin()
+	pdf.TransformRotate(30, 105, 180)
+	pdf.SetFillPattern(pdf.AddHatchPattern("horizontal", 3, 1, 200, 60, 60))
+	pdf.Circle(105, 180, 35, "FD")
+	pdf.TransformEnd()
+	fileStr := example.Filename("Fpdf_AddTilingPattern")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_AddTilingPattern.pdf
+}
+
+// TestMeshGradient verifies the shading dictionaries and data written for
+// mesh and patch gradients
+func TestMeshGradient(t *testing.T) {
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.AddPage()
+	red, green, blue := gofpdf.RGBType{R: 255}, gofpdf.RGBType{G: 255}, gofpdf.RGBType{B: 255}
+	pdf.MeshGradient([]gofpdf.ShadedTriangle{{{X: 10, Y: 10, Color: red},
+		{X: 110, Y: 10, Color: green}, {X: 60, Y: 90, Color: blue}}})
+	pdf.LatticeGradient([][]gofpdf.MeshVertex{
+		{{X: 10, Y: 100, Color: red}, {X: 110, Y: 100, Color: green}},
+		{{X: 10, Y: 200, Color: blue}, {X: 110, Y: 200, Color: red}},
+	})
+	patch := gofpdf.CoonsPatch{Colors: [4]gofpdf.RGBType{red, green, blue, red}}
+	for j := 0; j < 3; j++ {
+		v := float64(j) * 10
+		patch.Points[j] = gofpdf.PointType{X: 200, Y: 300 - v}
+		patch.Points[3+j] = gofpdf.PointType{X: 200 + v, Y: 270}
+		patch.Points[6+j] = gofpdf.PointType{X: 230, Y: 270 + v}
+		patch.Points[9+j] = gofpdf.PointType{X: 230 - v, Y: 300}
+	}
+	pdf.PatchGradient([]gofpdf.CoonsPatch{patch})
+	tensor := patch
+	tensor.Interior = []gofpdf.PointType{{X: 210, Y: 290}, {X: 210, Y: 280}, {X: 220, Y: 280},
+		{X: 220, Y: 290}}
+	pdf.PatchGradient([]gofpdf.CoonsPatch{patch, tensor})
+	doc := pdfParse(t, pdf)
+	if count := pdfCount(doc.content(1), "sh"); count != 4 {
+		t.Fatalf("expecting 4 shadings to be painted, got %d", count)
+	}
+	// The decode ranges span the bounding box of the vertices, and the data
+	// holds a flag, two coordinates and three components for each vertex.
+	// Lattices have no flags, and a patch with interior points turns all
+	// patches of its gradient into tensor-product patches.
+	for _, c := range []struct {
+		typeStr, decodeStr string
+		length             int
+	}{
+		{"4", "[10 110 751.89 831.89 0 1 0 1 0 1]", 3 * 12},
+		{"5", "[10 110 641.89 741.89 0 1 0 1 0 1]", 4 * 11},
+		{"6", "[200 230 541.89 571.89 0 1 0 1 0 1]", 1 + 12*8 + 4*3},
+		{"7", "[200 230 541.89 571.89 0 1 0 1 0 1]", 2 * (1 + 16*8 + 4*3)},
+	} {
+		list := doc.find("/ShadingType", c.typeStr)
+		if len(list) != 1 {
+			t.Fatalf("expecting one shading of type %s, got %d", c.typeStr, len(list))
+		}
+		if decodeStr := fmt.Sprint(pdfNums(list[0]["/Decode"])); decodeStr != c.decodeStr {
+			t.Fatalf("unexpected decode ranges %s for shading of type %s", decodeStr, c.typeStr)
+		}
+		if length := int(pdfNum(list[0]["/Length"])); length != c.length {
+			t.Fatalf("expecting %d bytes of data for shading of type %s, got %d", c.length, c.typeStr, length)
+		}
+	}
+	pdf.LatticeGradient([][]gofpdf.MeshVertex{{{}, {}}, {{}}})
+	if !pdf.Err() {
+		t.Fatalf("irregular lattice not reported")
+	}
+}
+
+// ExampleFpdf_MeshGradient demonstrates shadings made of triangles and
+// patches, clipped to a shape.
+func ExampleFpdf_MeshGradient() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.AddPage()
+	// Heat map from a lattice of measured values
+	heat := func(v float64) gofpdf.RGBType {
+		return gofpdf.RGBType{R: int(255 * v), G: int(80 + 100*math.Sin(math.Pi*v)), B: int(255 * (1 - v))}
+	}
+	var rows [][]gofpdf.MeshVertex
+	for j := 0; j <= 8; j++ {
+		var row []gofpdf.MeshVertex
+		for k := 0; k <= 10; k++ {
+			x, y := float64(k)/10, float64(j)/8
+			v := 0.5 + 0.5*math.Sin(3*x)*math.Cos(4*y)
+			row = append(row, gofpdf.MeshVertex{X: 20 + 80*x, Y: 20 + 60*y, Color: heat(v)})
+		}
+		rows = append(rows, row)
+	}
+	pdf.LatticeGradient(rows)
+	pdf.Rect(20, 20, 80, 60, "D")
+	pdf.Text(20, 87, "LatticeGradient()")
+	// Free-form triangles clipped to a circle
+	pdf.ClipCircle(150, 50, 30, false)
+	var triangles []gofpdf.ShadedTriangle
+	center := gofpdf.MeshVertex{X: 150, Y: 50, Color: gofpdf.RGBType{R: 255, G: 255, B: 255}}
+	for j := 0; j < 6; j++ {
+		a0, a1 := float64(j)*math.Pi/3, float64(j+1)*math.Pi/3
+		triangles = append(triangles, gofpdf.ShadedTriangle{center,
+			{X: 150 + 40*math.Cos(a0), Y: 50 + 40*math.Sin(a0), Color: heat(float64(j) / 6)},
+			{X: 150 + 40*math.Cos(a1), Y: 50 + 40*math.Sin(a1), Color: heat(float64(j+1) / 6)}})
+	}
+	pdf.MeshGradient(triangles)
+	pdf.ClipEnd()
+	pdf.Text(120, 87, "MeshGradient()")
+	// A curved Coons patch
+	patch := gofpdf.CoonsPatch{
+		Points: [12]gofpdf.PointType{{X: 30, Y: 190}, {X: 20, Y: 160}, {X: 40, Y: 130},
+			{X: 30, Y: 110}, {X: 60, Y: 100}, {X: 80, Y: 120}, {X: 100, Y: 110},
+			{X: 110, Y: 140}, {X: 90, Y: 170}, {X: 100, Y: 190}, {X: 70, Y: 200},
+			{X: 50, Y: 180}},
+		Colors: [4]gofpdf.RGBType{{R: 230, G: 40, B: 40}, {R: 250, G: 220, B: 60},
+			{R: 40, G: 180, B: 90}, {R: 40, G: 90, B: 220}},
+	}
+	pdf.PatchGradient([]gofpdf.CoonsPatch{patch})
+	pdf.Text(20, 207, "PatchGradient()")
+	// The same patch with interior control points pulled toward one corner
+	for j := range patch.Points {
+		patch.Points[j].X += 100
+	}
+	patch.Interior = []gofpdf.PointType{{X: 140, Y: 130}, {X: 145, Y: 125}, {X: 150, Y: 130},
+		{X: 145, Y: 135}}
+	pdf.PatchGradient([]gofpdf.CoonsPatch{patch})
+	pdf.Text(120, 207, "PatchGradient() with interior points")
+	fileStr := example.Filename("Fpdf_MeshGradient")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_MeshGradient.pdf
+}
+
+// TestBeginSoftMask verifies that drawing captured for a soft mask is written
+// to the mask form rather than to the page and that the drawing state is
+// restored afterward
+func TestBeginSoftMask(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.SetFillColor(255, 0, 0)
+	pdf.BeginSoftMask("Luminosity")
+	pdf.SetFillColor(255, 255, 255)
+	pdf.Rect(10, 10, 50, 50, "F")
+	mask := pdf.EndSoftMask()
+	if r, g, b := pdf.GetFillColor(); r != 255 || g != 0 || b != 0 {
+		t.Fatalf("fill color not restored after soft mask: %d %d %d", r, g, b)
+	}
+	pdf.TransformBegin()
+	pdf.ApplySoftMask(mask)
+	pdf.SetFillGradient(gofpdf.Gradient{X1: 0, Y1: 0, X2: 100, Y2: 0, Stops: []gofpdf.GradientStop{
+		{Offset: 0, R: 255, Alpha: 1}, {Offset: 1, B: 255, Alpha: 0}}})
+	pdf.Rect(0, 0, 100, 100, "F")
+	pdf.SetFillColor(0, 0, 255)
+	pdf.Rect(0, 0, 100, 100, "F")
+	pdf.TransformEnd()
+	pdf.SetFillColor(0, 255, 0)
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.4" {
+		t.Fatalf("unexpected version %s", doc.version)
+	}
+	ops := doc.content(1)
+	if count := pdfCount(ops, "re"); count != 2 {
+		t.Fatalf("expecting mask rectangle only in the mask form, got %d rectangles on page", count)
+	}
+	smask := pdfDict(doc.dict(doc.resource(1, "/ExtGState", "/SM1"))["/SMask"])
+	if smask["/S"] != "/Luminosity" {
+		t.Fatalf("unexpected soft mask %v", smask)
+	}
+	if pdfDict(doc.dict(smask["/G"])["/Group"])["/CS"] != "/DeviceGray" {
+		t.Fatalf("mask form not a gray transparency group")
+	}
+	// The mask form establishes the drawing state of the page
+	formOps := pdfOps(doc.stream(smask["/G"]))
+	j := pdfFind(formOps, 0, "rg", "1", "0", "0")
+	if pdfFind(formOps, 0, "Tf") < 0 || j < 0 || pdfFind(formOps, j, "g", "1") < 0 ||
+		pdfFind(formOps, j, "re", "28.35", "813.54", "141.73", "-141.73") < 0 {
+		t.Fatalf("drawing state of page not established in mask form")
+	}
+	// The mask of the gradient replaces the applied mask only for the
+	// gradient fill, and the graphics state restored by TransformEnd() has
+	// no soft mask
+	j = pdfFind(ops, 0, "gs", "/SM1")
+	if j = pdfFind(ops, j, "gs", "/SM2"); j < 0 {
+		t.Fatalf("mask of gradient not applied")
+	}
+	if j = pdfFind(ops, j, "Q"); j < 0 || ops[j+1].op != "rg" {
+		t.Fatalf("mask of gradient applied beyond gradient fill")
+	}
+	if j = pdfFind(ops, j+1, "Q"); j < 0 || pdfFind(ops, j, "rg", "0", "1", "0") != j+1 {
+		t.Fatalf("soft mask not ended with transformation")
+	}
+
+	// A mask drawn within a transformation is not transformed a second time
+	// when it is applied under the same transformation
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.TransformBegin()
+	pdf.TransformTranslateX(20)
+	pdf.BeginSoftMask("Luminosity")
+	pdf.Rect(10, 10, 50, 50, "F")
+	mask = pdf.EndSoftMask()
+	pdf.ApplySoftMask(mask)
+	pdf.Rect(10, 10, 50, 50, "F")
+	pdf.TransformEnd()
+	doc = pdfParse(t, pdf)
+	ref := pdfDict(doc.dict(doc.resource(1, "/ExtGState", "/SM1"))["/SMask"])["/G"]
+	if pdfCount(doc.content(1), "cm") != 1 || pdfCount(pdfOps(doc.stream(ref)), "cm") != 0 {
+		t.Fatalf("expecting transformation only on the page")
+	}
+	if bbox := fmt.Sprint(pdfNums(doc.dict(ref)["/BBox"])); bbox != "[-56.69291 0 538.58709 841.89]" {
+		t.Fatalf("mask bounds %s not in the transformed user space", bbox)
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.BeginSoftMask("Alpha")
+	pdf.AddPage()
+	pdf.EndSoftMask()
+	if pdf.Err() == false {
+		t.Fatalf("expected error adding a page while drawing a soft mask")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.BeginSoftMask("Alpha")
+	pdf.TransformBegin()
+	pdf.EndSoftMask()
+	if pdf.Err() == false {
+		t.Fatalf("expected error ending a soft mask with an open transformation")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.BeginSoftMask("Inverse")
+	if pdf.Err() == false {
+		t.Fatalf("expected error for unrecognized soft mask kind")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.SetXY(20, 30)
+	pdf.BeginSoftMask("Luminosity")
+	pdf.Cell(40, 10, "Mask")
+	pdf.EndSoftMask()
+	if x, y := pdf.GetXY(); x != 20 || y != 30 {
+		t.Fatalf("position not restored after soft mask: %.2f, %.2f", x, y)
+	}
+	pdf.BeginTransparencyGroup(false, false, 0.5, "")
+	if pdf.Output(ioutil.Discard) == nil {
+		t.Fatalf("expected error for transparency group that is not ended")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.BeginSoftMask("Alpha")
+	pdf.Begin()
+	if pdf.Err() == false {
+		t.Fatalf("expected error beginning a transaction while drawing a soft mask")
+	}
+	// A soft mask left open within a transaction is abandoned by a rollback
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.Cell(40, 10, "Kept")
+	pdf.Begin()
+	pdf.BeginSoftMask("Alpha")
+	pdf.Rect(10, 10, 50, 50, "F")
+	pdf.Rollback()
+	pdf.Cell(40, 10, "After")
+	doc = pdfParse(t, pdf)
+	ops = doc.content(1)
+	if pdfFind(ops, 0, "Tj", "(Kept)") < 0 || pdfFind(ops, 0, "Tj", "(After)") < 0 ||
+		pdfCount(ops, "re") != 0 {
+		t.Fatalf("soft mask drawing not abandoned by rollback")
+	}
+	for _, mp := range doc.find("/Type", "/ExtGState") {
+		if _, ok := mp["/SMask"]; ok {
+			t.Fatalf("soft mask not abandoned by rollback")
+		}
+	}
+}
+
+// ExampleFpdf_BeginSoftMask demonstrates soft masks drawn with gradients,
+// text and images
+func ExampleFpdf_BeginSoftMask() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.AddPage()
+	// Photo that fades into the background from left to right
+	pdf.BeginSoftMask("Luminosity")
+	pdf.SetFillGradient(gofpdf.Gradient{X1: 20, Y1: 0, X2: 100, Y2: 0, Stops: []gofpdf.GradientStop{
+		{Offset: 0.3, R: 255, G: 255, B: 255, Alpha: 1},
+		{Offset: 1, R: 0, G: 0, B: 0, Alpha: 1},
+	}})
+	pdf.Rect(20, 20, 80, 80, "F")
+	fade := pdf.EndSoftMask()
+	pdf.ApplySoftMask(fade)
+	pdf.ImageOptions(example.ImageFile("golang-gopher.png"), 20, 20, 80, 80, false,
+		gofpdf.ImageOptions{}, 0, "")
+	pdf.ApplySoftMask(0)
+	pdf.Text(20, 108, "Gradient fade")
+	// Vignette of a colored panel
+	pdf.BeginSoftMask("Luminosity")
+	pdf.SetFillGradient(gofpdf.Gradient{Radial: true, X1: 150, Y1: 60, X2: 150, Y2: 60, R2: 40,
+		Stops: []gofpdf.GradientStop{
+			{Offset: 0.5, R: 255, G: 255, B: 255, Alpha: 1},
+			{Offset: 1, R: 0, G: 0, B: 0, Alpha: 1},
+		}})
+	pdf.Rect(110, 20, 80, 80, "F")
+	vignette := pdf.EndSoftMask()
+	pdf.ApplySoftMask(vignette)
+	pdf.SetFillColor(40, 90, 200)
+	pdf.Rect(110, 20, 80, 80, "F")
+	pdf.ApplySoftMask(0)
+	pdf.Text(110, 108, "Vignette")
+	// Stripes seen through text
+	pdf.SetFont("Helvetica", "B", 60)
+	pdf.BeginSoftMask("Alpha")
+	pdf.Text(20, 150, "Soft mask")
+	text := pdf.EndSoftMask()
+	pdf.ApplySoftMask(text)
+	for j := 0; j < 12; j++ {
+		pdf.SetFillColor(200-j*15, 60+j*10, 120+j*10)
+		pdf.Rect(20, 125+float64(j)*3, 170, 3, "F")
+	}
+	pdf.ApplySoftMask(0)
+	// Grayscale image used as a mask
+	mask := pdf.SoftMaskImage(example.ImageFile("logo-gray.png"), 20, 170, 80, 0)
+	pdf.ApplySoftMask(mask)
+	pdf.SetFillColor(220, 60, 40)
+	pdf.Rect(20, 170, 80, 80, "F")
+	pdf.ApplySoftMask(0)
+	fileStr := example.Filename("Fpdf_BeginSoftMask")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_BeginSoftMask.pdf
+}
+
+// TestBeginTransparencyGroup verifies the form written for a transparency
+// group and the operators that draw it
+func TestBeginTransparencyGroup(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetAlpha(0.8, "Normal")
+	pdf.BeginTransparencyGroup(true, false, 0.5, "Multiply")
+	if alpha, _ := pdf.GetAlpha(); alpha != 1 {
+		t.Fatalf("expected opaque drawing within group, got alpha %.2f", alpha)
+	}
+	pdf.SetFillColor(255, 0, 0)
+	pdf.Rect(10, 10, 40, 40, "F")
+	pdf.BeginTransparencyGroup(false, true, 1, "")
+	pdf.Circle(50, 50, 20, "F")
+	pdf.EndTransparencyGroup()
+	pdf.EndTransparencyGroup()
+	if alpha, blendStr := pdf.GetAlpha(); alpha != 0.8 || blendStr != "Normal" {
+		t.Fatalf("alpha not restored after group: %.2f %s", alpha, blendStr)
+	}
+	if r, g, b := pdf.GetFillColor(); r != 0 || g != 0 || b != 0 {
+		t.Fatalf("fill color not restored after group: %d %d %d", r, g, b)
+	}
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.4" {
+		t.Fatalf("unexpected version %s", doc.version)
+	}
+	// The outer group is drawn on the page with its alpha and blend mode, and
+	// the inner group is drawn within the outer one
+	ops := doc.content(1)
+	j := pdfFind(ops, 0, "Do")
+	if j < 0 || pdfCount(ops, "Do") != 1 || pdfCount(ops, "re") != 0 {
+		t.Fatalf("expecting only the outer group to be drawn on the page")
+	}
+	gs := doc.dict(doc.resource(1, "/ExtGState", ops[j-1].args[0]))
+	if pdfNum(gs["/ca"]) != 0.5 || gs["/BM"] != "/Multiply" {
+		t.Fatalf("outer group not drawn with its alpha and blend mode")
+	}
+	outer := doc.resource(1, "/XObject", ops[j].args[0])
+	if group := pdfDict(doc.dict(outer)["/Group"]); group["/I"] != "true" || group["/K"] != "false" {
+		t.Fatalf("unexpected attributes %v of outer group", group)
+	}
+	ops = pdfOps(doc.stream(outer))
+	if j = pdfFind(ops, 0, "re"); j < 0 || pdfFind(ops, j, "Do") < 0 {
+		t.Fatalf("inner group not drawn after rectangle of outer group")
+	}
+	inner := doc.resource(1, "/XObject", ops[pdfFind(ops, j, "Do")].args[0])
+	if group := pdfDict(doc.dict(inner)["/Group"]); group["/I"] != "false" || group["/K"] != "true" {
+		t.Fatalf("unexpected attributes %v of inner group", group)
+	}
+	if ops = pdfOps(doc.stream(inner)); pdfFind(ops, 0, "c") < 0 || pdfCount(ops, "f") != 1 {
+		t.Fatalf("circle not drawn within inner group")
+	}
+
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.BeginSoftMask("Luminosity")
+	pdf.EndTransparencyGroup()
+	if pdf.Err() == false {
+		t.Fatalf("expected error ending a group while drawing a soft mask")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.BeginTransparencyGroup(false, false, 1.5, "Normal")
+	if pdf.Err() == false {
+		t.Fatalf("expected error for out of range group alpha")
+	}
+}
+
+// ExampleFpdf_BeginTransparencyGroup demonstrates fading shapes made of
+// overlapping parts as a unit
+func ExampleFpdf_BeginTransparencyGroup() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.AddPage()
+	backdrop := func(y float64) {
+		for j := 0; j < 9; j++ {
+			pdf.SetFillColor(230-j*20, 230-j*5, 250)
+			pdf.Rect(15+float64(j)*20, y, 20, 50, "F")
+		}
+		pdf.SetFillColor(220, 50, 50)
+	}
+	parts := func(x, y float64) {
+		pdf.Circle(x, y, 15, "F")
+		pdf.Circle(x+18, y, 15, "F")
+		pdf.Circle(x+9, y+14, 15, "F")
+	}
+	// Each part faded separately shows the overlaps
+	backdrop(20)
+	pdf.SetAlpha(0.5, "Normal")
+	parts(40, 38)
+	pdf.SetAlpha(1, "Normal")
+	pdf.Text(25, 80, "SetAlpha()")
+	// The parts faded as one unit
+	pdf.SetFillColor(220, 50, 50)
+	pdf.BeginTransparencyGroup(false, false, 0.5, "Normal")
+	parts(130, 38)
+	pdf.EndTransparencyGroup()
+	pdf.Text(115, 80, "BeginTransparencyGroup()")
+	// Semi-transparent parts of a knockout group replace each other
+	backdrop(100)
+	pdf.BeginTransparencyGroup(false, false, 1, "Normal")
+	pdf.SetAlpha(0.5, "Normal")
+	parts(40, 118)
+	pdf.EndTransparencyGroup()
+	pdf.Text(25, 160, "Non-knockout group")
+	pdf.BeginTransparencyGroup(false, true, 1, "Normal")
+	pdf.SetAlpha(0.5, "Normal")
+	parts(130, 118)
+	pdf.EndTransparencyGroup()
+	pdf.Text(115, 160, "Knockout group")
+	// An isolated group keeps blend modes from reaching the backdrop
+	backdrop(180)
+	pdf.BeginTransparencyGroup(false, false, 1, "Normal")
+	pdf.SetAlpha(1, "Multiply")
+	parts(40, 198)
+	pdf.EndTransparencyGroup()
+	pdf.Text(25, 240, "Non-isolated group")
+	pdf.BeginTransparencyGroup(true, false, 1, "Normal")
+	pdf.SetAlpha(1, "Multiply")
+	parts(130, 198)
+	pdf.EndTransparencyGroup()
+	pdf.Text(115, 240, "Isolated group")
+	fileStr := example.Filename("Fpdf_BeginTransparencyGroup")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_BeginTransparencyGroup.pdf
+}
+
+// TestSetDrawCMYK verifies the operators written for CMYK colors and that
+// CMYK colors are retained by pages, templates and StateType
+func TestSetDrawCMYK(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.SetDrawCMYK(100, 0, 0, 0)
+	pdf.SetFillCMYK(0, 100, 0, 0)
+	pdf.SetTextCMYK(0, 0, 100, 120)
+	pdf.AddPage()
+	if c, m, y, k := pdf.GetTextCMYK(); c != 0 || m != 0 || y != 100 || k != 100 {
+		t.Fatalf("unexpected text color %d %d %d %d", c, m, y, k)
+	}
+	pdf.Rect(10, 10, 20, 20, "FD")
+	pdf.Text(10, 40, "CMYK")
+	tpl := pdf.CreateTemplate(func(tpl *gofpdf.Tpl) {
+		tpl.Rect(0, 0, 10, 10, "F")
+	})
+	pdf.UseTemplate(tpl)
+	st := gofpdf.StateGet(pdf)
+	pdf.SetFillColor(255, 0, 0)
+	if c, m, y, k := pdf.GetFillCMYK(); c != 0 || m != 0 || y != 0 || k != 0 {
+		t.Fatalf("expected no CMYK fill color, got %d %d %d %d", c, m, y, k)
+	}
+	st.Put(pdf)
+	if _, m, _, _ := pdf.GetFillCMYK(); m != 100 {
+		t.Fatalf("CMYK fill color not restored by StateType")
+	}
+	doc := pdfParse(t, pdf)
+	// The page and the template begin with the colors of the document, and
+	// the text color is set for the text only
+	ops := doc.content(1)
+	j := pdfFind(ops, 0, "K", "1", "0", "0", "0")
+	if j < 0 || pdfFind(ops, j, "k", "0", "1", "0", "0") != j+1 || pdfFind(ops, j, "re") < 0 {
+		t.Fatalf("CMYK colors of document not set on page")
+	}
+	if j = pdfFind(ops, 0, "k", "0", "0", "1", "1"); j < 0 || ops[j+1].op != "BT" {
+		t.Fatalf("CMYK text color not set for text")
+	}
+	if j = pdfFind(ops, j, "Do"); j < 0 {
+		t.Fatalf("template not drawn")
+	}
+	tplOps := pdfOps(doc.stream(doc.resource(1, "/XObject", ops[j].args[0])))
+	if k := pdfFind(tplOps, 0, "K", "1", "0", "0", "0"); k < 0 ||
+		pdfFind(tplOps, k, "k", "0", "1", "0", "0") != k+1 || pdfFind(tplOps, k, "re") < 0 {
+		t.Fatalf("CMYK colors of document not set in template")
+	}
+	// Restoring the state replaces the RGB fill color
+	if j = pdfFind(ops, j, "rg", "1", "0", "0"); j < 0 || pdfFind(ops, j, "k", "0", "1", "0", "0") < 0 {
+		t.Fatalf("CMYK fill color not restored by StateType")
+	}
+}
+
+// ExampleFpdf_SetDrawCMYK demonstrates drawing with ink-based CMYK colors,
+// as required by many printers
+func ExampleFpdf_SetDrawCMYK() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.AddPage()
+	// Process color swatches with tints
+	inks := []struct {
+		name       string
+		c, m, y, k byte
+	}{
+		{"Cyan", 100, 0, 0, 0},
+		{"Magenta", 0, 100, 0, 0},
+		{"Yellow", 0, 0, 100, 0},
+		{"Black", 0, 0, 0, 100},
+		{"Rich black", 60, 40, 40, 100},
+	}
+	for j, ink := range inks {
+		y := 20 + float64(j)*22
+		pdf.SetTextCMYK(0, 0, 0, 100)
+		pdf.Text(20, y+10, ink.name)
+		for tint := 1; tint <= 5; tint++ {
+			scale := func(v byte) byte { return byte(int(v) * tint / 5) }
+			pdf.SetFillCMYK(scale(ink.c), scale(ink.m), scale(ink.y), scale(ink.k))
+			pdf.Rect(45+float64(tint-1)*25, y, 22, 15, "F")
+		}
+	}
+	// Cell with CMYK border, background and text
+	pdf.SetDrawCMYK(100, 60, 0, 20)
+	pdf.SetFillCMYK(10, 5, 0, 0)
+	pdf.SetTextCMYK(100, 60, 0, 20)
+	pdf.SetLineWidth(0.8)
+	pdf.SetXY(20, 135)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(150, 15, "Print-ready CMYK", "1", 0, "C", true, 0, "")
+	fileStr := example.Filename("Fpdf_SetDrawCMYK")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetDrawCMYK.pdf
+}
+
+// TestSetOutputIntent verifies that ICC profiles embedded in images and set
+// for the document are written once as ICCBased color spaces
+func TestSetOutputIntent(t *testing.T) {
+	profile, err := ioutil.ReadFile(example.ImageFile("srgb.icc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetOutputIntent(profile, "sRGB IEC61966-2.1")
+	pdf.SetDefaultColorProfile(profile)
+	pdf.AddPage()
+	pdf.Image(example.ImageFile("logo-icc.jpg"), 10, 10, 30, 0, false, "", 0, "")
+	info := pdf.RegisterImageOptions(example.ImageFile("logo-icc.png"), gofpdf.ImageOptions{})
+	pdf.Image(example.ImageFile("logo-icc.png"), 10, 50, 30, 0, false, "", 0, "")
+	pdf.Image(example.ImageFile("logo.png"), 10, 90, 30, 0, false, "", 0, "")
+	// The profile survives encoding of the image information
+	buf, err := info.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded gofpdf.ImageInfoType
+	err = decoded.GobDecode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reencoded, _ := decoded.GobEncode(); !bytes.Equal(buf, reencoded) {
+		t.Fatalf("image profile lost in encoding")
+	}
+	doc := pdfParse(t, pdf)
+	if doc.version != "1.4" {
+		t.Fatalf("unexpected version %s", doc.version)
+	}
+	if count := len(doc.find("/Alternate", "/DeviceRGB")); count != 1 {
+		t.Fatalf("expected a single shared profile, found %d", count)
+	}
+	list := pdfItems(doc.catalog()["/OutputIntents"])
+	if len(list) != 1 {
+		t.Fatalf("expected one output intent, found %d", len(list))
+	}
+	intent := pdfDict(list[0])
+	if intent["/S"] != "/GTS_PDFX" || intent["/OutputConditionIdentifier"] != "(sRGB IEC61966-2.1)" {
+		t.Fatalf("unexpected output intent %v", intent)
+	}
+	profileRef := intent["/DestOutputProfile"]
+	if doc.dict(profileRef)["/Alternate"] != "/DeviceRGB" {
+		t.Fatalf("output intent does not refer to the profile")
+	}
+	// Images with embedded profiles and the default RGB color space of the
+	// page refer to the same profile
+	count := 0
+	for _, mp := range doc.find("/Subtype", "/Image") {
+		if list = pdfItems(mp["/ColorSpace"]); len(list) == 2 && list[0] == "/ICCBased" {
+			if pdfRef(list[1]) != pdfRef(profileRef) {
+				t.Fatalf("image does not refer to the shared profile")
+			}
+			count++
+		}
+	}
+	if count != 2 {
+		t.Fatalf("expected profile for each image with embedded profile, found %d", count)
+	}
+	if list = pdfItems(doc.resource(1, "/ColorSpace", "/DefaultRGB")); len(list) != 2 ||
+		list[0] != "/ICCBased" || pdfRef(list[1]) != pdfRef(profileRef) {
+		t.Fatalf("default RGB color space not set")
+	}
+
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetOutputIntent([]byte("not a profile"), "")
+	if pdf.Err() == false {
+		t.Fatalf("expected error for invalid output intent profile")
+	}
+}
+
+// ExampleFpdf_SetOutputIntent demonstrates a document with color profiles
+// for consistent reproduction of colors and images
+func ExampleFpdf_SetOutputIntent() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	profile, err := ioutil.ReadFile(example.ImageFile("srgb.icc"))
+	if err == nil {
+		// Colors and images without a profile are interpreted with the
+		// same profile as the intended output device
+		pdf.SetOutputIntent(profile, "sRGB")
+		pdf.SetDefaultColorProfile(profile)
+	} else {
+		pdf.SetError(err)
+	}
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.AddPage()
+	pdf.SetFillColor(230, 80, 40)
+	pdf.Rect(20, 20, 50, 30, "F")
+	pdf.Text(20, 58, "Color with default profile")
+	// The profiles embedded in these images are used for their colors
+	pdf.Image(example.ImageFile("logo-icc.jpg"), 20, 70, 50, 0, false, "", 0, "")
+	pdf.Text(20, 120, "JPEG with embedded profile")
+	pdf.Image(example.ImageFile("logo-icc.png"), 110, 70, 50, 0, false, "", 0, "")
+	pdf.Text(110, 120, "PNG with embedded profile")
+	fileStr := example.Filename("Fpdf_SetOutputIntent")
+	err = pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetOutputIntent.pdf
+}
+
+// TestSetOverprint verifies that overprint settings and rendering intents are
+// combined with the alpha settings in ExtGState entries
+func TestSetOverprint(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetAlpha(0.5, "Multiply")
+	pdf.SetOverprint(false, true, 1)
+	pdf.SetRenderingIntent("Perceptual")
+	pdf.SetAlpha(1, "Normal")
+	pdf.SetOverprint(false, false, 0)
+	if stroke, fill, mode := pdf.GetOverprint(); stroke || fill || mode != 0 {
+		t.Fatalf("unexpected overprint settings %v %v %d", stroke, fill, mode)
+	}
+	if intentStr := pdf.GetRenderingIntent(); intentStr != "Perceptual" {
+		t.Fatalf("unexpected rendering intent %s", intentStr)
+	}
+	doc := pdfParse(t, pdf)
+	ops := doc.content(1)
+	if count := pdfCount(ops, "gs"); count != 5 {
+		t.Fatalf("expecting 5 graphics states, got %d", count)
+	}
+	// Each graphics state carries all settings in effect when it is selected
+	j := 0
+	for _, want := range []string{
+		"0.500 /Multiply    ",
+		"0.500 /Multiply false true 1 ",
+		"0.500 /Multiply false true 1 /Perceptual",
+		"1.000 /Normal false true 1 /Perceptual",
+		"1.000 /Normal false false 0 /Perceptual",
+	} {
+		j = pdfFind(ops, j, "gs") + 1
+		gs := doc.dict(doc.resource(1, "/ExtGState", ops[j-1].args[0]))
+		got := fmt.Sprintf("%s %s %s %s %s %s", gs["/ca"], gs["/BM"], gs["/OP"], gs["/op"], gs["/OPM"], gs["/RI"])
+		if got != want {
+			t.Fatalf("expected graphics state %q, got %q", want, got)
+		}
+	}
+
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetOverprint(true, true, 2)
+	if pdf.Err() == false {
+		t.Fatalf("expected error for invalid overprint mode")
+	}
+	pdf = gofpdf.New("P", "mm", "A4", "")
+	pdf.SetRenderingIntent("Vivid")
+	if pdf.Err() == false {
+		t.Fatalf("expected error for unrecognized rendering intent")
+	}
+}
+
+// ExampleFpdf_SetOverprint demonstrates overprinted black text and a trap
+// between spot colors, as prepared for commercial printing
+func ExampleFpdf_SetOverprint() {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddSpotColor("PANTONE 185 C", 0, 93, 79, 0)
+	pdf.AddSpotColor("PANTONE 286 C", 100, 66, 0, 2)
+	pdf.SetRenderingIntent("RelativeColorimetric")
+	pdf.AddPage()
+	// Black text overprints the background so that no gaps appear around
+	// the letters if the plates are misregistered
+	pdf.SetFillCMYK(20, 0, 60, 0)
+	pdf.Rect(20, 20, 170, 40, "F")
+	pdf.SetFont("Helvetica", "B", 28)
+	pdf.SetTextCMYK(0, 0, 0, 100)
+	pdf.SetOverprint(true, true, 1)
+	pdf.Text(30, 45, "Overprinted black text")
+	pdf.SetOverprint(false, false, 0)
+	// A spot color shape knocks out the one below, and an overprinted
+	// outline traps the shared edge
+	pdf.SetFillSpotColor("PANTONE 185 C", 100)
+	pdf.Rect(20, 80, 80, 60, "F")
+	pdf.SetFillSpotColor("PANTONE 286 C", 100)
+	pdf.Circle(100, 110, 25, "F")
+	pdf.SetDrawSpotColor("PANTONE 286 C", 100)
+	pdf.SetLineWidth(0.5)
+	pdf.SetOverprint(true, false, 0)
+	pdf.Circle(100, 110, 25, "D")
+	pdf.SetOverprint(false, false, 0)
+	fileStr := example.Filename("Fpdf_SetOverprint")
+	err := pdf.OutputFileAndClose(fileStr)
+	example.Summary(err, fileStr)
+	// Output:
+	// Successfully generated pdf/Fpdf_SetOverprint.pdf
+}
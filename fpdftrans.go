@@ -14,6 +14,42 @@ type TransformMatrix struct {
 	A, B, C, D, E, F float64
 }
 
+// identityMatrix leaves coordinates unchanged
+var identityMatrix = TransformMatrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns the matrix that applies tm followed by m
+func (tm TransformMatrix) multiply(m TransformMatrix) TransformMatrix {
+	return TransformMatrix{
+		A: tm.A*m.A + tm.B*m.C,
+		B: tm.A*m.B + tm.B*m.D,
+		C: tm.C*m.A + tm.D*m.C,
+		D: tm.C*m.B + tm.D*m.D,
+		E: tm.E*m.A + tm.F*m.C + m.E,
+		F: tm.E*m.B + tm.F*m.D + m.F,
+	}
+}
+
+// invert returns the inverse of tm. ok is false if tm cannot be inverted.
+func (tm TransformMatrix) invert() (inv TransformMatrix, ok bool) {
+	det := tm.A*tm.D - tm.B*tm.C
+	if math.Abs(det) < 1e-12 {
+		return
+	}
+	return TransformMatrix{
+		A: tm.D / det,
+		B: -tm.B / det,
+		C: -tm.C / det,
+		D: tm.A / det,
+		E: (tm.C*tm.F - tm.D*tm.E) / det,
+		F: (tm.B*tm.E - tm.A*tm.F) / det,
+	}, true
+}
+
+// apply returns the point (x, y) transformed by tm
+func (tm TransformMatrix) apply(x, y float64) (float64, float64) {
+	return tm.A*x + tm.C*y + tm.E, tm.B*x + tm.D*y + tm.F
+}
+
 // TransformBegin sets up a transformation context for subsequent text,
 // drawings and images. The typical usage is to immediately follow a call to
 // this method with a call to one or more of the transformation methods such as
@@ -22,6 +58,8 @@ type TransformMatrix struct {
 // contexts must be properly ended prior to outputting the document.
 func (f *Fpdf) TransformBegin() {
 	f.transformNest++
+	f.ctmStack = append(f.ctmStack, f.ctm)
+	f.softMaskStack = append(f.softMaskStack, f.softMask)
 	f.out("q")
 }
 
@@ -195,6 +233,7 @@ func (f *Fpdf) Transform(tm TransformMatrix) {
 	if f.transformNest > 0 {
 		f.outf("%.5f %.5f %.5f %.5f %.5f %.5f cm",
 			tm.A, tm.B, tm.C, tm.D, tm.E, tm.F)
+		f.ctm = tm.multiply(f.ctm)
 	} else if f.err == nil {
 		f.err = fmt.Errorf("transformation context is not active")
 	}
@@ -206,8 +245,54 @@ func (f *Fpdf) Transform(tm TransformMatrix) {
 func (f *Fpdf) TransformEnd() {
 	if f.transformNest > 0 {
 		f.transformNest--
+		if n := len(f.ctmStack); n > 0 {
+			f.ctm = f.ctmStack[n-1]
+			f.ctmStack = f.ctmStack[:n-1]
+		}
+		if n := len(f.softMaskStack); n > 0 {
+			f.softMask = f.softMaskStack[n-1]
+			f.softMaskStack = f.softMaskStack[:n-1]
+		}
 		f.out("Q")
 	} else {
 		f.err = fmt.Errorf("error attempting to end transformation operation out of sequence")
 	}
 }
+
+// GetTransform returns the current transformation matrix, that is, the
+// combined effect of the transformations applied since the outermost active
+// call to TransformBegin(). Like the argument of Transform(), the matrix maps
+// points to points with the origin at the lower left corner of the page. The
+// identity matrix is returned if no transformation is active.
+func (f *Fpdf) GetTransform() TransformMatrix {
+	return f.ctm
+}
+
+// UserToPage returns the position on the page, in the units established in
+// New() and measured from the upper left corner of the page, at which the
+// point (x, y) of the current transformed coordinate system appears. This
+// can be used to place untransformed content, such as callouts and leader
+// lines, relative to transformed drawings.
+//
+// The UserToPage() example demonstrates this method.
+func (f *Fpdf) UserToPage(x, y float64) (pageX, pageY float64) {
+	pageX, pageY = f.ctm.apply(x*f.k, f.hPt-y*f.k)
+	return pageX / f.k, (f.hPt - pageY) / f.k
+}
+
+// PageToUser is the inverse of UserToPage(). It returns the point of the
+// current transformed coordinate system that appears at position (x, y) of
+// the page. An error is set if the current transformation cannot be inverted.
+//
+// The UserToPage() example demonstrates this method.
+func (f *Fpdf) PageToUser(x, y float64) (userX, userY float64) {
+	inv, ok := f.ctm.invert()
+	if !ok {
+		if f.err == nil {
+			f.err = fmt.Errorf("transformation matrix is not invertible")
+		}
+		return x, y
+	}
+	userX, userY = inv.apply(x*f.k, f.hPt-y*f.k)
+	return userX / f.k, (f.hPt - userY) / f.k
+}
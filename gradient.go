@@ -0,0 +1,343 @@
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// GradientStop specifies a color stop of a Gradient. Offset is the position of
+// the stop along the gradient, from 0 (start) to 1 (end). R, G and B are the
+// color components, ranging from 0 to 255. Alpha is the opacity of the color,
+// from 0.0 (fully transparent) to 1.0 (fully opaque).
+type GradientStop struct {
+	Offset  float64
+	R, G, B int
+	Alpha   float64
+}
+
+// Gradient describes a color gradient with any number of color stops. It is
+// used with SetFillGradient() and SetTextGradient().
+//
+// A linear gradient blends its colors along the axis from (X1, Y1) to
+// (X2, Y2); the colors are constant perpendicularly to the axis. If Radial is
+// true, the colors are blended from the circle with center (X1, Y1) and radius
+// R1 to the circle with center (X2, Y2) and radius R2. Coordinates and radii
+// are specified in the units established in New(), relative to the upper left
+// corner of the page.
+//
+// Stops specifies the colors and their positions. Stops are sorted by offset;
+// where two stops have the same offset, the color changes abruptly. The first
+// color is used from the start of the gradient up to the first stop and the
+// last color from the last stop to the end. Beyond the start and end of the
+// gradient, nothing is painted unless ExtendStart or ExtendEnd is true, in
+// which case the first or last color is used.
+type Gradient struct {
+	Radial                 bool
+	X1, Y1, R1             float64
+	X2, Y2, R2             float64
+	Stops                  []GradientStop
+	ExtendStart, ExtendEnd bool
+}
+
+// SetFillGradient sets the fill color to the gradient g, so that subsequent
+// filled shapes, such as those drawn with Rect(), Circle(), Polygon(),
+// RoundedRect() and DrawPath(), and cell backgrounds are painted with it. The
+// gradient is positioned according to the transformation in effect when this
+// method is called.
+//
+// If some stops are not fully opaque, each filled shape is drawn with a soft
+// mask that applies only to that shape, and the PDF version of the document is
+// raised to 1.4. The mask replaces any mask set with ApplySoftMask() while the
+// shape is drawn. For a path built with MoveTo() and painted with DrawPath(),
+// the mask is applied when MoveTo() is first called, so the gradient must be
+// set before the path is begun; the mask then applies to the stroke of the
+// path as well.
+//
+// The SetFillGradient() example demonstrates this method.
+func (f *Fpdf) SetFillGradient(g Gradient) {
+	clr, ok := f.gradientColor(g)
+	if !ok {
+		return
+	}
+	f.color.fill = clr
+	f.colorFlag = f.color.fill.str != f.color.text.str
+	if f.page > 0 {
+		f.out(f.color.fill.str)
+	}
+}
+
+// SetTextGradient sets the text color to the gradient g. The gradient is
+// positioned according to the transformation in effect when this method is
+// called. The soft mask of a gradient that is not fully opaque applies only to
+// the text.
+//
+// The SetFillGradient() example demonstrates this method.
+func (f *Fpdf) SetTextGradient(g Gradient) {
+	clr, ok := f.gradientColor(g)
+	if ok {
+		if clr.mask > 0 {
+			// Text is written within its own graphics state
+			clr.str = sprintf("/SM%d gs %s", clr.mask, clr.str)
+		}
+		f.color.text = clr
+		f.colorFlag = f.color.fill.str != f.color.text.str
+	}
+}
+
+// fillMaskBegin applies the soft mask of the current fill color, if it has
+// one, to a path that is about to be constructed and painted with the
+// operator opStr if opStr fills the path. True is returned if the mask was
+// applied, in which case fillMaskEnd() is called after the path is painted.
+func (f *Fpdf) fillMaskBegin(opStr string) bool {
+	if f.color.fill.mask == 0 || f.page == 0 || !strings.ContainsAny(opStr, "fFbB") {
+		return false
+	}
+	f.outf("q /SM%d gs", f.color.fill.mask)
+	return true
+}
+
+// fillMaskEnd removes the soft mask applied by fillMaskBegin() if masked is
+// true
+func (f *Fpdf) fillMaskEnd(masked bool) {
+	if masked {
+		f.out("Q")
+	}
+}
+
+// gradientColor registers the shading pattern for g and returns the color
+// that selects it. ok is false if g is invalid.
+func (f *Fpdf) gradientColor(g Gradient) (clr colorType, ok bool) {
+	if f.err != nil {
+		return
+	}
+	if len(g.Stops) == 0 {
+		f.err = fmt.Errorf("gradient must have at least one color stop")
+		return
+	}
+	if g.Radial && (g.R1 < 0 || g.R2 < 0 || (g.R1 == 0 && g.R2 == 0)) {
+		f.err = fmt.Errorf("invalid radial gradient radii %.2f and %.2f", g.R1, g.R2)
+		return
+	}
+	opaque := true
+	for _, s := range g.Stops {
+		if s.Alpha < 0 || s.Alpha > 1 {
+			f.err = fmt.Errorf("gradient stop alpha value (0.0 - 1.0) is out of range: %.3f", s.Alpha)
+			return
+		}
+		opaque = opaque && s.Alpha == 1
+	}
+	g.Stops = gradientStops(g.Stops)
+	matrix := TransformMatrix{f.k, 0, 0, -f.k, 0, f.hPt}.multiply(f.ctm)
+	keyStr := sprintf("%v %v", g, matrix)
+	pos, found := f.patternMap[keyStr]
+	if !found {
+		pat := patternType{shading: f.gradientAdd(g, false), matrix: matrix}
+		if !opaque {
+			pat.mask = f.gradientMask(g)
+		}
+		pos = f.patternAdd(keyStr, pat)
+	}
+	first := g.Stops[0]
+	clr = rgbColorValue(first.R, first.G, first.B, "", "")
+	clr.mode = colorModePattern
+	clr.mask = f.patternList[pos].mask
+	clr.str = sprintf("/Pattern cs /P%d scn", pos)
+	return clr, true
+}
+
+// gradientStops returns a copy of stops sorted by offset, with offsets
+// clamped to the range 0 to 1 and stops added at offsets 0 and 1 if needed
+func gradientStops(stops []GradientStop) (list []GradientStop) {
+	list = make([]GradientStop, 0, len(stops)+2)
+	for _, s := range stops {
+		s.Offset = math.Max(0, math.Min(1, s.Offset))
+		list = append(list, s)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Offset < list[j].Offset
+	})
+	if list[0].Offset > 0 {
+		s := list[0]
+		s.Offset = 0
+		list = append([]GradientStop{s}, list...)
+	}
+	if s := list[len(list)-1]; s.Offset < 1 {
+		s.Offset = 1
+		list = append(list, s)
+	}
+	return
+}
+
+// gradientAdd adds the shading for g to the gradient list and returns its
+// index. If gray is true, the shading blends the alpha values of the stops.
+func (f *Fpdf) gradientAdd(g Gradient, gray bool) int {
+	gr := gradientType{tp: 2, x1: g.X1, y1: g.Y1, x2: g.X2, y2: g.Y2, stops: g.Stops,
+		extend: [2]bool{g.ExtendStart, g.ExtendEnd}, gray: gray}
+	if g.Radial {
+		gr.tp, gr.r1, gr.r = 3, g.R1, g.R2
+	}
+	f.gradientList = append(f.gradientList, gr)
+	return len(f.gradientList) - 1
+}
+
+// gradientMask adds a luminosity soft mask that paints the alpha values of
+// the stops of g and returns its index
+func (f *Fpdf) gradientMask(g Gradient) int {
+	sh := f.gradientAdd(g, true)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%.5f 0 0 %.5f 0 %.5f cm\n/Sh%d sh", f.k, -f.k, f.hPt, sh)
+	// The mask covers the page in the current user space
+	return f.softMaskAdd(softMaskType{kind: "Luminosity", bbox: f.pageBBox(), content: buf.Bytes()})
+}
+
+// pageBBox returns the bounds of the page, in points, in the coordinate
+// system established by the current transformation
+func (f *Fpdf) pageBBox() [4]float64 {
+	inv, ok := f.ctm.invert()
+	if !ok {
+		inv = identityMatrix
+	}
+	x0, y0 := math.Inf(1), math.Inf(1)
+	x1, y1 := math.Inf(-1), math.Inf(-1)
+	for _, pt := range []PointType{{0, 0}, {f.wPt, 0}, {0, f.hPt}, {f.wPt, f.hPt}} {
+		x, y := inv.apply(pt.X, pt.Y)
+		x0, x1 = math.Min(x0, x), math.Max(x1, x)
+		y0, y1 = math.Min(y0, y), math.Max(y1, y)
+	}
+	return [4]float64{x0, y0, x1, y1}
+}
+
+// softMaskAdd adds mask to the soft mask list and returns its index. Soft
+// masks require PDF version 1.4.
+func (f *Fpdf) softMaskAdd(mask softMaskType) int {
+	if len(f.softMaskList) == 0 {
+		f.softMaskList = append(f.softMaskList, softMaskType{}) // softMaskList[0] removes the mask
+	}
+	f.softMaskList = append(f.softMaskList, mask)
+	if f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+	return len(f.softMaskList) - 1
+}
+
+// putGradientStops writes the functions and shading of the gradient with
+// color stops at index j of the gradient list
+func (f *Fpdf) putGradientStops(j int) {
+	gr := f.gradientList[j]
+	comps := func(s GradientStop) string {
+		if gr.gray {
+			return sprintf("%.3f", s.Alpha)
+		}
+		clr := rgbColorValue(s.R, s.G, s.B, "", "")
+		return clr.str
+	}
+	var funcs []int
+	var bounds []float64
+	for k := 0; k+1 < len(gr.stops); k++ {
+		s0, s1 := gr.stops[k], gr.stops[k+1]
+		if s1.Offset <= s0.Offset {
+			continue
+		}
+		f.newobj()
+		f.outf("<</FunctionType 2 /Domain [0.0 1.0] /C0 [%s] /C1 [%s] /N 1>>", comps(s0), comps(s1))
+		f.out("endobj")
+		if len(funcs) > 0 {
+			bounds = append(bounds, s0.Offset)
+		}
+		funcs = append(funcs, f.n)
+	}
+	fn := funcs[0]
+	if len(funcs) > 1 {
+		// Stitch the functions of the intervals between stops
+		var fb, bb, eb fmtBuffer
+		for k, n := range funcs {
+			if k > 0 {
+				fb.printf(" ")
+				eb.printf(" ")
+			}
+			fb.printf("%d 0 R", n)
+			eb.printf("0 1")
+		}
+		for k, b := range bounds {
+			if k > 0 {
+				bb.printf(" ")
+			}
+			bb.printf("%.5f", b)
+		}
+		f.newobj()
+		f.outf("<</FunctionType 3 /Domain [0.0 1.0] /Functions [%s] /Bounds [%s] /Encode [%s]>>",
+			fb.String(), bb.String(), eb.String())
+		f.out("endobj")
+		fn = f.n
+	}
+	cs := "DeviceRGB"
+	if gr.gray {
+		cs = "DeviceGray"
+	}
+	f.newobj()
+	f.outf("<</ShadingType %d /ColorSpace /%s", gr.tp, cs)
+	if gr.tp == 2 {
+		f.outf("/Coords [%.5f %.5f %.5f %.5f]", gr.x1, gr.y1, gr.x2, gr.y2)
+	} else {
+		f.outf("/Coords [%.5f %.5f %.5f %.5f %.5f %.5f]", gr.x1, gr.y1, gr.r1, gr.x2, gr.y2, gr.r)
+	}
+	f.outf("/Function %d 0 R /Extend [%v %v]>>", fn, gr.extend[0], gr.extend[1])
+	f.out("endobj")
+	f.gradientList[j].objNum = f.n
+}
+
+// putPatterns writes the shading and tiling pattern objects
+func (f *Fpdf) putPatterns() {
+	for j := 1; j < len(f.patternList); j++ {
+		pat := f.patternList[j]
+		if pat.tiling > 0 {
+			f.putTilingPattern(j)
+			continue
+		}
+		m := pat.matrix
+		f.newobj()
+		f.outf("<</Type /Pattern /PatternType 2 /Shading %d 0 R /Matrix [%.5f %.5f %.5f %.5f %.5f %.5f]>>",
+			f.gradientList[pat.shading].objNum, m.A, m.B, m.C, m.D, m.E, m.F)
+		f.out("endobj")
+		f.patternList[j].objNum = f.n
+	}
+}
+
+// putSoftMasks writes the form of each soft mask and the ExtGState object that
+// sets it. The forms use the document resource dictionary.
+func (f *Fpdf) putSoftMasks() {
+	filter := ""
+	if f.compress {
+		filter = "/Filter /FlateDecode "
+	}
+	for j, mask := range f.softMaskList {
+		if j == 0 {
+			f.newobj()
+			f.out("<</Type /ExtGState /SMask /None>>")
+			f.out("endobj")
+			f.softMaskList[j].objNum = f.n
+			continue
+		}
+		cs := "DeviceGray"
+		if mask.kind == "Alpha" {
+			cs = "DeviceRGB"
+		}
+		buffer := mask.content
+		if f.compress {
+			buffer = sliceCompress(buffer)
+		}
+		f.newobj()
+		f.outf("<<%s/Type /XObject /Subtype /Form /BBox [%.5f %.5f %.5f %.5f]", filter,
+			mask.bbox[0], mask.bbox[1], mask.bbox[2], mask.bbox[3])
+		f.outf("/Group <</S /Transparency /CS /%s>> /Resources 2 0 R /Length %d>>", cs, len(buffer))
+		f.putstream(buffer)
+		f.out("endobj")
+		f.newobj()
+		f.outf("<</Type /ExtGState /SMask <</Type /Mask /S /%s /G %d 0 R>>>>", mask.kind, f.n-1)
+		f.out("endobj")
+		f.softMaskList[j].objNum = f.n
+	}
+}
@@ -23,12 +23,23 @@ type RGBAType struct {
 // StateType holds various commonly used drawing values for convenient
 // retrieval (StateGet()) and restore (Put) methods.
 type StateType struct {
-	clrDraw, clrText, clrFill RGBType
-	lineWd                    float64
-	fontSize                  float64
-	alpha                     float64
-	blendStr                  string
-	cellMargin                float64
+	clrDraw, clrText, clrFill    RGBType
+	cmykDraw, cmykText, cmykFill *cmykColorType // CMYK colors, nil if not in use
+	lineWd                       float64
+	fontSize                     float64
+	alpha                        float64
+	blendStr                     string
+	cellMargin                   float64
+}
+
+// stateCMYK returns the CMYK components of clr, or nil if clr is not a CMYK
+// color
+func stateCMYK(clr colorType) *cmykColorType {
+	if clr.mode != colorModeCMYK {
+		return nil
+	}
+	cmyk := clr.cmyk
+	return &cmyk
 }
 
 // StateGet returns a variable that contains common state values.
@@ -36,6 +47,9 @@ func StateGet(pdf *Fpdf) (st StateType) {
 	st.clrDraw.R, st.clrDraw.G, st.clrDraw.B = pdf.GetDrawColor()
 	st.clrFill.R, st.clrFill.G, st.clrFill.B = pdf.GetFillColor()
 	st.clrText.R, st.clrText.G, st.clrText.B = pdf.GetTextColor()
+	st.cmykDraw = stateCMYK(pdf.color.draw)
+	st.cmykFill = stateCMYK(pdf.color.fill)
+	st.cmykText = stateCMYK(pdf.color.text)
 	st.lineWd = pdf.GetLineWidth()
 	_, st.fontSize = pdf.GetFontSize()
 	st.alpha, st.blendStr = pdf.GetAlpha()
@@ -46,9 +60,21 @@ func StateGet(pdf *Fpdf) (st StateType) {
 // Put sets the common state values contained in the state structure
 // specified by st.
 func (st StateType) Put(pdf *Fpdf) {
-	pdf.SetDrawColor(st.clrDraw.R, st.clrDraw.G, st.clrDraw.B)
-	pdf.SetFillColor(st.clrFill.R, st.clrFill.G, st.clrFill.B)
-	pdf.SetTextColor(st.clrText.R, st.clrText.G, st.clrText.B)
+	if c := st.cmykDraw; c != nil {
+		pdf.SetDrawCMYK(c.c, c.m, c.y, c.k)
+	} else {
+		pdf.SetDrawColor(st.clrDraw.R, st.clrDraw.G, st.clrDraw.B)
+	}
+	if c := st.cmykFill; c != nil {
+		pdf.SetFillCMYK(c.c, c.m, c.y, c.k)
+	} else {
+		pdf.SetFillColor(st.clrFill.R, st.clrFill.G, st.clrFill.B)
+	}
+	if c := st.cmykText; c != nil {
+		pdf.SetTextCMYK(c.c, c.m, c.y, c.k)
+	} else {
+		pdf.SetTextColor(st.clrText.R, st.clrText.G, st.clrText.B)
+	}
 	pdf.SetLineWidth(st.lineWd)
 	pdf.SetFontUnitSize(st.fontSize)
 	pdf.SetAlpha(st.alpha, st.blendStr)
@@ -0,0 +1,86 @@
+package gofpdf
+
+// BeginTransparencyGroup begins a transparency group. Everything drawn until
+// EndTransparencyGroup() is called is composited into the group first, and
+// the result is then drawn on the page with the opacity alpha, from 0.0
+// (fully transparent) to 1.0 (fully opaque), and the blend mode blendModeStr,
+// which takes the values accepted by SetAlpha(). This way, a shape made of
+// several overlapping parts can be faded as one unit without the parts
+// showing through each other.
+//
+// If isolated is true, the content of the group is composited on a fully
+// transparent backdrop rather than on what was drawn before it, so that blend
+// modes used within the group do not interact with the page. If knockout is
+// true, each object in the group is composited with the backdrop of the group
+// rather than with the objects of the group drawn before it, so that
+// overlapping semi-transparent parts replace rather than combine with each
+// other.
+//
+// Within the group, drawing begins fully opaque with the "Normal" blend mode
+// and no soft mask; the current colors, line style and font are retained.
+// Changes to these and to the current position made within the group do not
+// affect subsequent drawing.
+// Groups may be nested. Transformations and clipping begun within the group
+// must be ended before EndTransparencyGroup() is called, and pages may not be
+// added. Transparency groups require PDF version 1.4.
+//
+// The BeginTransparencyGroup() example demonstrates this method.
+func (f *Fpdf) BeginTransparencyGroup(isolated, knockout bool, alpha float64, blendModeStr string) {
+	if f.err != nil {
+		return
+	}
+	gs := f.blendAdd(alpha, blendModeStr)
+	if gs == 0 {
+		return
+	}
+	// The group is drawn in the current user space, so its bounds are those
+	// of the page in that space
+	f.captureBegin(&captureType{kind: "Group", group: groupType{isolated: isolated,
+		knockout: knockout, gs: gs, bbox: f.pageBBox()}})
+}
+
+// EndTransparencyGroup ends the transparency group begun with
+// BeginTransparencyGroup() and draws it on the page. The drawing state in
+// effect when BeginTransparencyGroup() was called is restored.
+//
+// The BeginTransparencyGroup() example demonstrates this method.
+func (f *Fpdf) EndTransparencyGroup() {
+	c, content := f.captureEnd(true)
+	if c == nil {
+		return
+	}
+	gr := c.group
+	gr.content = content
+	if len(f.groupList) == 0 {
+		f.groupList = append(f.groupList, groupType{}) // groupList[0] is unused
+	}
+	f.groupList = append(f.groupList, gr)
+	if f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+	f.outf("q /GS%d gs /TG%d Do Q", gr.gs, len(f.groupList)-1)
+}
+
+// putGroups writes the form of each transparency group. The forms use the
+// document resource dictionary.
+func (f *Fpdf) putGroups() {
+	filter := ""
+	if f.compress {
+		filter = "/Filter /FlateDecode "
+	}
+	for j := 1; j < len(f.groupList); j++ {
+		gr := f.groupList[j]
+		buffer := gr.content
+		if f.compress {
+			buffer = sliceCompress(buffer)
+		}
+		f.newobj()
+		f.outf("<<%s/Type /XObject /Subtype /Form /BBox [%.5f %.5f %.5f %.5f]", filter,
+			gr.bbox[0], gr.bbox[1], gr.bbox[2], gr.bbox[3])
+		f.outf("/Group <</S /Transparency /CS /DeviceRGB /I %v /K %v>> /Resources 2 0 R /Length %d>>",
+			gr.isolated, gr.knockout, len(buffer))
+		f.putstream(buffer)
+		f.out("endobj")
+		f.groupList[j].objNum = f.n
+	}
+}
@@ -0,0 +1,242 @@
+package gofpdf
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// iccType holds an ICC color profile written as an ICCBased color space
+type iccType struct {
+	data   []byte
+	n      int // number of color components
+	objNum int
+}
+
+// outputIntentType holds the output intent of the document
+type outputIntentType struct {
+	icc          int // index into iccList of destination profile
+	conditionStr string
+}
+
+// iccComponents returns the number of color components of the ICC profile
+// data and the device color space that serves as its alternate, or 0 if data
+// is not a supported profile
+func iccComponents(data []byte) (n int, csStr string) {
+	if len(data) < 128 || string(data[36:40]) != "acsp" {
+		return
+	}
+	switch string(data[16:20]) {
+	case "GRAY":
+		return 1, "DeviceGray"
+	case "RGB ":
+		return 3, "DeviceRGB"
+	case "CMYK":
+		return 4, "DeviceCMYK"
+	}
+	return
+}
+
+// iccAdd adds the ICC profile data to the profile list, unless an identical
+// profile is already present, and returns its index. 0 is returned if data is
+// not a supported profile.
+func (f *Fpdf) iccAdd(data []byte) int {
+	n, _ := iccComponents(data)
+	if n == 0 {
+		return 0
+	}
+	keyStr := fmt.Sprintf("%x", sha1.Sum(data))
+	pos, ok := f.iccMap[keyStr]
+	if !ok {
+		if f.iccMap == nil {
+			f.iccMap = make(map[string]int)
+			f.iccList = append(f.iccList, iccType{}) // iccList[0] is unused
+		}
+		pos = len(f.iccList)
+		f.iccList = append(f.iccList, iccType{data: data, n: n})
+		f.iccMap[keyStr] = pos
+	}
+	return pos
+}
+
+// SetOutputIntent specifies the ICC profile of the device on which the
+// document is intended to be printed or displayed, for example the profile of
+// a printing condition such as "FOGRA39". profile holds the content of an ICC
+// profile file for gray, RGB or CMYK output. conditionStr identifies the
+// printing condition. The output intent is required by print-ready formats
+// such as PDF/X. Output intents require PDF version 1.4.
+//
+// The SetOutputIntent() example demonstrates this method.
+func (f *Fpdf) SetOutputIntent(profile []byte, conditionStr string) {
+	if f.err != nil {
+		return
+	}
+	pos := f.iccAdd(profile)
+	if pos == 0 {
+		f.err = fmt.Errorf("output intent profile is not a gray, RGB or CMYK ICC profile")
+		return
+	}
+	f.outputIntent = outputIntentType{icc: pos, conditionStr: conditionStr}
+	if f.pdfVersion < "1.4" {
+		f.pdfVersion = "1.4"
+	}
+}
+
+// SetDefaultColorProfile specifies the ICC profile with which device colors
+// are interpreted. profile holds the content of an ICC profile file. Depending
+// on the kind of profile, it applies to the gray, RGB or CMYK colors set with
+// methods such as SetFillColor() and SetFillCMYK(), and to images without an
+// embedded profile, so that they are reproduced consistently on different
+// devices. Profiles of each kind may be set independently. The profiles apply
+// to the pages of the document but not to the content of templates.
+//
+// Profiles embedded in JPEG and PNG images are used for those images
+// automatically.
+//
+// The SetOutputIntent() example demonstrates this method.
+func (f *Fpdf) SetDefaultColorProfile(profile []byte) {
+	if f.err != nil {
+		return
+	}
+	pos := f.iccAdd(profile)
+	if pos == 0 {
+		f.err = fmt.Errorf("default color profile is not a gray, RGB or CMYK ICC profile")
+		return
+	}
+	if f.iccDefaults == nil {
+		f.iccDefaults = make(map[string]int)
+	}
+	switch f.iccList[pos].n {
+	case 1:
+		f.iccDefaults["DefaultGray"] = pos
+	case 3:
+		f.iccDefaults["DefaultRGB"] = pos
+	case 4:
+		f.iccDefaults["DefaultCMYK"] = pos
+	}
+}
+
+// jpegICCProfile returns the ICC profile embedded in the APP2 segments of the
+// JPEG data, or nil if there is none
+func jpegICCProfile(data []byte) []byte {
+	var chunks [][]byte
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			// Image data follows the start of scan
+			break
+		}
+		segLen := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if segLen < 2 || pos+2+segLen > len(data) {
+			break
+		}
+		seg := data[pos+4 : pos+2+segLen]
+		if marker == 0xE2 && len(seg) > 14 && string(seg[:12]) == "ICC_PROFILE\x00" {
+			// A profile may be split into numbered chunks
+			seq, count := int(seg[12]), int(seg[13])
+			if chunks == nil {
+				chunks = make([][]byte, count)
+			}
+			if seq < 1 || seq > len(chunks) {
+				return nil
+			}
+			chunks[seq-1] = seg[14:]
+		}
+		pos += 2 + segLen
+	}
+	var profile []byte
+	for _, chunk := range chunks {
+		if chunk == nil {
+			return nil
+		}
+		profile = append(profile, chunk...)
+	}
+	return profile
+}
+
+// pngICCProfile returns the ICC profile held by the content of a PNG iCCP
+// chunk, or nil if it cannot be decompressed
+func pngICCProfile(chunk []byte) []byte {
+	pos := bytes.IndexByte(chunk, 0)
+	if pos < 0 || pos+2 > len(chunk) || chunk[pos+1] != 0 {
+		return nil
+	}
+	profile, err := sliceUncompress(chunk[pos+2:])
+	if err != nil {
+		return nil
+	}
+	return profile
+}
+
+// imageColorSpace returns the color space of image info for use in its
+// dictionary, based on its embedded ICC profile if it has one that matches its
+// device color space
+func (f *Fpdf) imageColorSpace(info *ImageInfoType) string {
+	csStr := info.cs
+	if csStr == "Indexed" {
+		csStr = "DeviceRGB"
+	}
+	if _, altStr := iccComponents(info.icc); altStr == csStr {
+		if pos, ok := f.iccMap[fmt.Sprintf("%x", sha1.Sum(info.icc))]; ok {
+			return sprintf("[/ICCBased %d 0 R]", f.iccList[pos].objNum)
+		}
+	}
+	return "/" + csStr
+}
+
+// putICCProfiles writes the ICC profiles of images, of the output intent and
+// of default color spaces
+func (f *Fpdf) putICCProfiles() {
+	var keyList []string
+	for key, image := range f.images {
+		if len(image.icc) > 0 {
+			keyList = append(keyList, key)
+		}
+	}
+	sort.Strings(keyList)
+	for _, key := range keyList {
+		f.iccAdd(f.images[key].icc)
+	}
+	for j := 1; j < len(f.iccList); j++ {
+		icc := f.iccList[j]
+		_, altStr := iccComponents(icc.data)
+		filter := ""
+		buffer := icc.data
+		if f.compress {
+			filter = "/Filter /FlateDecode "
+			buffer = sliceCompress(buffer)
+		}
+		f.newobj()
+		f.outf("<<%s/N %d /Alternate /%s /Length %d>>", filter, icc.n, altStr, len(buffer))
+		f.putstream(buffer)
+		f.out("endobj")
+		f.iccList[j].objNum = f.n
+	}
+}
+
+// iccPutColorSpaces writes the default color spaces in the color space
+// resource dictionary
+func (f *Fpdf) iccPutColorSpaces() {
+	for _, nameStr := range []string{"DefaultGray", "DefaultRGB", "DefaultCMYK"} {
+		if pos, ok := f.iccDefaults[nameStr]; ok {
+			f.outf("/%s [/ICCBased %d 0 R]", nameStr, f.iccList[pos].objNum)
+		}
+	}
+}
+
+// putOutputIntents writes the output intent of the document in the catalog
+func (f *Fpdf) putOutputIntents() {
+	if f.outputIntent.icc == 0 {
+		return
+	}
+	f.outf("/OutputIntents [<</Type /OutputIntent /S /GTS_PDFX /OutputConditionIdentifier %s /Info %s /DestOutputProfile %d 0 R>>]",
+		f.textstring(f.outputIntent.conditionStr), f.textstring(f.outputIntent.conditionStr),
+		f.iccList[f.outputIntent.icc].objNum)
+}
@@ -114,8 +114,5 @@ func (f *Fpdf) layerPutCatalog() {
 			}
 		}
 		f.outf("/OCProperties <</OCGs [%s] /D <</OFF [%s] /Order [%s]>>>>", onStr, offStr, onStr)
-		if f.layer.openLayerPane {
-			f.out("/PageMode /UseOC")
-		}
 	}
 }
@@ -0,0 +1,230 @@
+package gofpdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// MeshVertex is a point with a color, used to describe the shaded triangles
+// of MeshGradient() and the lattice of LatticeGradient(). X and Y are
+// specified in the units established in New().
+type MeshVertex struct {
+	X, Y  float64
+	Color RGBType
+}
+
+// ShadedTriangle is a triangle whose color is blended between the colors of
+// its vertices
+type ShadedTriangle [3]MeshVertex
+
+// CoonsPatch is an area bounded by four cubic Bézier curves whose color is
+// blended between the colors of its corners. Points holds the boundary in
+// order around the patch: a corner, the two control points of the curve to
+// the next corner, that corner, and so on, with the last two points being the
+// control points of the curve back to the first corner. Colors holds the
+// colors of the corners Points[0], Points[3], Points[6] and Points[9].
+// Coordinates are specified in the units established in New().
+//
+// If Interior holds four points, the patch is a tensor-product patch whose
+// interior is further shaped by these control points, listed in the order of
+// the corners they are nearest to, beginning with Points[0].
+type CoonsPatch struct {
+	Points   [12]PointType
+	Colors   [4]RGBType
+	Interior []PointType
+}
+
+// meshRecord holds the points and colors of a vertex or patch of a mesh
+// shading
+type meshRecord struct {
+	flag   int // edge flag, -1 if the shading type has none
+	points []PointType
+	colors []RGBType
+}
+
+// MeshGradient paints triangles whose colors are blended between the colors
+// of their vertices. Shapes such as heat maps can be built from such
+// triangles. The area outside the triangles is left unchanged. To limit the
+// shading to an arbitrary area, call this method between a clipping method
+// such as ClipPolygon() and ClipEnd().
+//
+// The MeshGradient() example demonstrates this method.
+func (f *Fpdf) MeshGradient(triangles []ShadedTriangle) {
+	if len(triangles) == 0 {
+		f.SetErrorf("mesh gradient must have at least one triangle")
+		return
+	}
+	records := make([]meshRecord, 0, 3*len(triangles))
+	for _, tri := range triangles {
+		for _, v := range tri {
+			records = append(records, meshRecord{points: []PointType{{X: v.X, Y: v.Y}},
+				colors: []RGBType{v.Color}})
+		}
+	}
+	f.meshGradient(4, records, 0)
+}
+
+// LatticeGradient paints a grid of quadrilaterals, each divided into two
+// triangles whose colors are blended between the colors of their vertices.
+// vertices holds the rows of the grid, each with the same number of at least
+// two vertices. Adjacent rows form a band of quadrilaterals. The area outside
+// the grid is left unchanged.
+//
+// The MeshGradient() example demonstrates this method.
+func (f *Fpdf) LatticeGradient(vertices [][]MeshVertex) {
+	if len(vertices) < 2 || len(vertices[0]) < 2 {
+		f.SetErrorf("lattice gradient must have at least two rows of two vertices")
+		return
+	}
+	perRow := len(vertices[0])
+	var records []meshRecord
+	for _, row := range vertices {
+		if len(row) != perRow {
+			f.SetErrorf("lattice gradient rows must have the same number of vertices")
+			return
+		}
+		for _, v := range row {
+			records = append(records, meshRecord{flag: -1, points: []PointType{{X: v.X, Y: v.Y}},
+				colors: []RGBType{v.Color}})
+		}
+	}
+	f.meshGradient(5, records, perRow)
+}
+
+// PatchGradient paints Coons or tensor-product patches whose colors are
+// blended between the colors of their corners. Patches may be curved and
+// folded, which makes them suitable for smooth shaded illustrations. The area
+// outside the patches is left unchanged.
+//
+// The MeshGradient() example demonstrates this method.
+func (f *Fpdf) PatchGradient(patches []CoonsPatch) {
+	if len(patches) == 0 {
+		f.SetErrorf("patch gradient must have at least one patch")
+		return
+	}
+	tp := 6
+	for _, p := range patches {
+		switch len(p.Interior) {
+		case 0:
+		case 4:
+			tp = 7
+		default:
+			f.SetErrorf("tensor-product patch must have four interior points, not %d", len(p.Interior))
+			return
+		}
+	}
+	records := make([]meshRecord, 0, len(patches))
+	for _, p := range patches {
+		rec := meshRecord{points: append([]PointType{}, p.Points[:]...), colors: p.Colors[:]}
+		if tp == 7 {
+			interior := p.Interior
+			if len(interior) == 0 {
+				interior = patchInterior(p.Points)
+			}
+			rec.points = append(rec.points, interior...)
+		}
+		records = append(records, rec)
+	}
+	f.meshGradient(tp, records, 0)
+}
+
+// patchInterior returns the interior control points of the tensor-product
+// patch that is equivalent to the Coons patch with boundary points pts
+func patchInterior(pts [12]PointType) []PointType {
+	// Boundary points in the order p00 p01 p02 p03 p13 p23 p33 p32 p31 p30 p20 p10
+	comb := func(c ...float64) (pt PointType) {
+		for j, v := range c {
+			pt.X += v * pts[j].X / 9
+			pt.Y += v * pts[j].Y / 9
+		}
+		return
+	}
+	return []PointType{
+		// p11
+		comb(-4, 6, 0, -2, 3, 0, -1, 0, 3, -2, 0, 6),
+		// p12
+		comb(-2, 0, 6, -4, 6, 0, -2, 3, 0, -1, 0, 3),
+		// p22
+		comb(-1, 0, 3, -2, 0, 6, -4, 6, 0, -2, 3, 0),
+		// p21
+		comb(-2, 3, 0, -1, 0, 3, -2, 0, 6, -4, 6, 0),
+	}
+}
+
+// meshGradient adds a mesh shading of type tp with the vertices or patches
+// of records and paints it
+func (f *Fpdf) meshGradient(tp int, records []meshRecord, perRow int) {
+	if f.err != nil {
+		return
+	}
+	if f.page == 0 {
+		f.err = fmt.Errorf("a page must be added before painting a gradient")
+		return
+	}
+	// Coordinates are encoded relative to the range they cover
+	x0, y0 := math.Inf(1), math.Inf(1)
+	x1, y1 := math.Inf(-1), math.Inf(-1)
+	for j := range records {
+		for k, pt := range records[j].points {
+			pt = PointType{X: pt.X * f.k, Y: f.hPt - pt.Y*f.k}
+			records[j].points[k] = pt
+			x0, x1 = math.Min(x0, pt.X), math.Max(x1, pt.X)
+			y0, y1 = math.Min(y0, pt.Y), math.Max(y1, pt.Y)
+		}
+	}
+	if x1 == x0 {
+		x1++
+	}
+	if y1 == y0 {
+		y1++
+	}
+	var buf bytes.Buffer
+	coord := func(v, lo, hi float64) {
+		binary.Write(&buf, binary.BigEndian, uint32(math.Round((v-lo)/(hi-lo)*math.MaxUint32)))
+	}
+	for _, rec := range records {
+		if rec.flag >= 0 {
+			buf.WriteByte(byte(rec.flag))
+		}
+		for _, pt := range rec.points {
+			coord(pt.X, x0, x1)
+			coord(pt.Y, y0, y1)
+		}
+		for _, clr := range rec.colors {
+			for _, v := range []int{clr.R, clr.G, clr.B} {
+				v, _ = colorComp(v)
+				buf.WriteByte(byte(v))
+			}
+		}
+	}
+	pos := len(f.gradientList)
+	f.gradientList = append(f.gradientList, gradientType{tp: tp, mesh: buf.Bytes(),
+		decode: [4]float64{x0, x1, y0, y1}, perRow: perRow})
+	f.outf("/Sh%d sh", pos)
+}
+
+// putMeshGradient writes the mesh shading at index j of the gradient list
+func (f *Fpdf) putMeshGradient(j int) {
+	gr := f.gradientList[j]
+	filter := ""
+	buffer := gr.mesh
+	if f.compress {
+		filter = "/Filter /FlateDecode "
+		buffer = sliceCompress(buffer)
+	}
+	f.newobj()
+	f.outf("<<%s/ShadingType %d /ColorSpace /DeviceRGB /BitsPerCoordinate 32 /BitsPerComponent 8",
+		filter, gr.tp)
+	if gr.tp == 5 {
+		f.outf("/VerticesPerRow %d", gr.perRow)
+	} else {
+		f.out("/BitsPerFlag 8")
+	}
+	f.outf("/Decode [%.5f %.5f %.5f %.5f 0 1 0 1 0 1] /Length %d>>", gr.decode[0], gr.decode[1],
+		gr.decode[2], gr.decode[3], len(buffer))
+	f.putstream(buffer)
+	f.out("endobj")
+	f.gradientList[j].objNum = f.n
+}
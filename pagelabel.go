@@ -0,0 +1,272 @@
+package gofpdf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PageLabelStyle specifies the numbering style of a range of page labels set
+// with SetPageLabel().
+type PageLabelStyle string
+
+const (
+	// PageLabelNone labels pages with the prefix only
+	PageLabelNone PageLabelStyle = ""
+	// PageLabelDecimal numbers pages 1, 2, 3, ...
+	PageLabelDecimal PageLabelStyle = "D"
+	// PageLabelRomanUpper numbers pages I, II, III, ...
+	PageLabelRomanUpper PageLabelStyle = "R"
+	// PageLabelRomanLower numbers pages i, ii, iii, ...
+	PageLabelRomanLower PageLabelStyle = "r"
+	// PageLabelLetterUpper numbers pages A to Z, then AA to ZZ, and so on
+	PageLabelLetterUpper PageLabelStyle = "A"
+	// PageLabelLetterLower numbers pages a to z, then aa to zz, and so on
+	PageLabelLetterLower PageLabelStyle = "a"
+)
+
+type pageLabelType struct {
+	style  PageLabelStyle
+	prefix string
+	start  int
+}
+
+// SetPageLabel begins a range of page labels at page number startPage. PDF
+// viewers display these labels in place of physical page numbers. The range
+// extends to the page before the start of the next range, or to the end of
+// the document. Pages in the range are labeled with prefix followed by a
+// number in the specified style; the first page of the range is numbered
+// start, and values less than one are replaced with one. If no range begins
+// at the first page, the pages before the first range are labeled with
+// decimal page numbers. Calling this method again for the same startPage
+// replaces the earlier range.
+//
+// The startPage need not exist yet, so this method is typically called just
+// before AddPage() with a value of PageNo()+1. If startPage is removed with
+// DeletePage(), the range begins at the following page instead, unless
+// another range already begins there.
+//
+// The SetPageLabel() example demonstrates this method.
+func (f *Fpdf) SetPageLabel(startPage int, style PageLabelStyle, prefix string, start int) {
+	if f.err != nil {
+		return
+	}
+	switch style {
+	case PageLabelNone, PageLabelDecimal, PageLabelRomanUpper, PageLabelRomanLower,
+		PageLabelLetterUpper, PageLabelLetterLower:
+	default:
+		f.err = fmt.Errorf("invalid page label style \"%s\"", style)
+		return
+	}
+	if startPage < 1 {
+		f.err = fmt.Errorf("invalid page label start page %d", startPage)
+		return
+	}
+	if start < 1 {
+		start = 1
+	}
+	if f.pageLabels == nil {
+		f.pageLabels = make(map[int]pageLabelType)
+	}
+	f.pageLabels[startPage] = pageLabelType{style: style, prefix: prefix, start: start}
+}
+
+// PageLabel returns the label of page number n as established with
+// SetPageLabel(). If no labels have been set, the decimal page number is
+// returned. This method can be used in header and footer functions, typically
+// with PageNo() as its argument, to print the same label that viewers display.
+func (f *Fpdf) PageLabel(n int) string {
+	return pageLabelStr(f.pageLabels, n)
+}
+
+// AliasNbSectionPages defines an alias for the number of pages in the range of
+// page labels to which a page belongs. It is substituted on each page as the
+// document is closed, so that "page X of Y" can be printed within a section
+// by combining it with PageLabel(). An empty string is replaced with the
+// string "{snb}". If no page labels have been set, the alias is replaced with
+// the total number of pages.
+//
+// The SetPageLabel() example demonstrates this method.
+func (f *Fpdf) AliasNbSectionPages(aliasStr string) {
+	if aliasStr == "" {
+		aliasStr = "{snb}"
+	}
+	f.aliasNbSectionPagesStr = aliasStr
+}
+
+// pageLabelRange returns the first page of the label range containing page n
+// along with its definition. ok is false if n precedes the first range.
+func pageLabelRange(labels map[int]pageLabelType, n int) (first int, lbl pageLabelType, ok bool) {
+	for p, l := range labels {
+		if p <= n && p > first {
+			first, lbl, ok = p, l, true
+		}
+	}
+	return
+}
+
+// pageLabelStr returns the label of page n given the label ranges in labels
+func pageLabelStr(labels map[int]pageLabelType, n int) string {
+	first, lbl, ok := pageLabelRange(labels, n)
+	if !ok {
+		return strconv.Itoa(n)
+	}
+	v := lbl.start + n - first
+	switch lbl.style {
+	case PageLabelDecimal:
+		return lbl.prefix + strconv.Itoa(v)
+	case PageLabelRomanUpper:
+		return lbl.prefix + romanStr(v)
+	case PageLabelRomanLower:
+		return lbl.prefix + strings.ToLower(romanStr(v))
+	case PageLabelLetterUpper:
+		return lbl.prefix + letterStr(v)
+	case PageLabelLetterLower:
+		return lbl.prefix + strings.ToLower(letterStr(v))
+	}
+	return lbl.prefix
+}
+
+// romanStr returns v in upper case roman numerals
+func romanStr(v int) string {
+	var b strings.Builder
+	for _, r := range []struct {
+		val int
+		str string
+	}{{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"}} {
+		for v >= r.val {
+			b.WriteString(r.str)
+			v -= r.val
+		}
+	}
+	return b.String()
+}
+
+// letterStr returns v in the upper case letter style of PDF page labels: A to
+// Z, then AA to ZZ, and so on
+func letterStr(v int) string {
+	return strings.Repeat(string(rune('A'+(v-1)%26)), (v-1)/26+1)
+}
+
+// pageLabelSectionCount returns the number of pages, in a document of count
+// pages, in the label range that contains page n
+func pageLabelSectionCount(labels map[int]pageLabelType, n, count int) int {
+	first, _, ok := pageLabelRange(labels, n)
+	if !ok {
+		first = 1
+	}
+	last := count
+	for p := range labels {
+		if p > n && p-1 < last {
+			last = p - 1
+		}
+	}
+	return last - first + 1
+}
+
+// pageLabelReplaceAliases substitutes the section page count alias on each
+// page
+func (f *Fpdf) pageLabelReplaceAliases() {
+	if f.aliasNbSectionPagesStr == "" {
+		return
+	}
+	count := len(f.pages) - 1
+	for n := 1; n <= count; n++ {
+		replacement := strconv.Itoa(pageLabelSectionCount(f.pageLabels, n, count))
+		s := f.pages[n].String()
+		for mode := 0; mode < 2; mode++ {
+			alias, repl := f.aliasNbSectionPagesStr, replacement
+			if mode == 1 {
+				alias = utf8toutf16(alias, false)
+				repl = utf8toutf16(repl, false)
+			}
+			s = strings.Replace(s, alias, repl, -1)
+		}
+		f.pages[n].Truncate(0)
+		f.pages[n].WriteString(s)
+	}
+}
+
+// pageLabelRemap returns the label ranges after pages have been rearranged.
+// newPage maps current page numbers to new ones, with zero for removed pages.
+// A range whose first page is removed begins instead at the next surviving
+// page, unless another range already begins there.
+func pageLabelRemap(labels map[int]pageLabelType, newPage []int) map[int]pageLabelType {
+	if labels == nil {
+		return nil
+	}
+	mp := make(map[int]pageLabelType, len(labels))
+	shift := -(len(newPage) - 1)
+	for _, n := range newPage[1:] {
+		if n > 0 {
+			shift++
+		}
+	}
+	var pending []int
+	for p := range labels {
+		if p >= len(newPage) {
+			// Range begins at a page that has not been added yet
+			mp[p+shift] = labels[p]
+		} else if newPage[p] > 0 {
+			mp[newPage[p]] = labels[p]
+		} else {
+			pending = append(pending, p)
+		}
+	}
+	sort.Ints(pending)
+	for _, p := range pending {
+		for q := p + 1; q < len(newPage); q++ {
+			if n := newPage[q]; n > 0 {
+				if _, ok := labels[q]; !ok {
+					if _, ok = mp[n]; !ok {
+						mp[n] = labels[p]
+					}
+				}
+				break
+			}
+		}
+	}
+	return mp
+}
+
+// putPageLabels writes the /PageLabels entry of the catalog
+func (f *Fpdf) putPageLabels() {
+	if len(f.pageLabels) == 0 {
+		return
+	}
+	count := len(f.pages) - 1
+	keys := make([]int, 0, len(f.pageLabels))
+	for p := range f.pageLabels {
+		if p <= count {
+			keys = append(keys, p)
+		}
+	}
+	sort.Ints(keys)
+	var b strings.Builder
+	b.WriteString("/PageLabels <</Nums [")
+	if len(keys) == 0 || keys[0] != 1 {
+		b.WriteString("0 <</S /D>> ")
+	}
+	for _, p := range keys {
+		lbl := f.pageLabels[p]
+		fmt.Fprintf(&b, "%d <<", p-1)
+		if lbl.style != PageLabelNone {
+			fmt.Fprintf(&b, "/S /%s ", lbl.style)
+		}
+		if lbl.prefix != "" {
+			prefix := lbl.prefix
+			for _, r := range prefix {
+				if r > 127 {
+					prefix = utf8toutf16(prefix)
+					break
+				}
+			}
+			fmt.Fprintf(&b, "/P %s ", f.textstring(prefix))
+		}
+		fmt.Fprintf(&b, "/St %d>> ", lbl.start)
+	}
+	b.WriteString("]>>")
+	f.out(b.String())
+}
@@ -0,0 +1,287 @@
+package gofpdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TransitionOptions holds the optional parameters of a page transition. Each
+// parameter applies only to some transition styles; it is ignored for the
+// others.
+//
+// Dimension is "H" (the default) or "V" and selects horizontal or vertical
+// lines for the Split and Blinds styles. Motion is "I" (the default) or "O"
+// and selects inward or outward motion for the Split, Box and Fly styles.
+// Direction is the direction of motion in degrees, measured counter-clockwise
+// from left to right, for the Wipe, Glitter, Fly, Push, Cover and Uncover
+// styles; valid values are 0 (the default), 90, 180 and 270, except for
+// Glitter, which accepts 0, 270 and 315. Scale is the starting or ending scale of the Fly style, and
+// Opaque indicates that the area flown in is opaque.
+type TransitionOptions struct {
+	Dimension string
+	Motion    string
+	Direction int
+	Scale     float64
+	Opaque    bool
+}
+
+// PageTransition specifies the visual effect used when a viewer in
+// presentation mode moves to a page. Style is one of "Split", "Blinds", "Box",
+// "Wipe", "Dissolve", "Glitter", "Fly", "Push", "Cover", "Uncover", "Fade"
+// or "R" (simple replacement, the default). Duration is the length of the
+// effect in seconds; zero selects the viewer's default of one second.
+type PageTransition struct {
+	Style    string
+	Duration float64
+	TransitionOptions
+}
+
+// PageOptions specifies the attributes of a page added with
+// AddPageWithOptions().
+//
+// OrientationStr and Size determine the orientation and size of the page as
+// they do for AddPageFormat(); an empty string and a zero size select the
+// document defaults.
+//
+// Rotate is the number of degrees, a multiple of 90, by which the page is
+// rotated clockwise when it is displayed or printed. The content of the page
+// is not affected.
+//
+// UserUnit, if greater than zero, is the size of the default user space unit
+// in multiples of 1/72 inch. Viewers scale the whole page, its size as well as
+// its content, by this factor. Size and all positions on the page are
+// therefore given in scaled units: in a document measured in inches, a page
+// with a UserUnit of 10 and a Size of 200 by 300 is displayed at 2000 by 3000
+// inches. This permits pages larger than the 200 inch limit of most viewers.
+// Setting it raises the PDF version of the document to 1.6.
+//
+// Duration, if greater than zero, is the number of seconds the page is
+// displayed before a viewer in presentation mode advances to the next page.
+//
+// Transition, if not nil, is the effect used when moving to the page in
+// presentation mode.
+type PageOptions struct {
+	OrientationStr string
+	Size           SizeType
+	Rotate         int
+	UserUnit       float64
+	Duration       float64
+	Transition     *PageTransition
+}
+
+// pageAttrType holds page attributes that are not reflected in the page size
+type pageAttrType struct {
+	rotate      int
+	userUnit    float64
+	dur         float64
+	trans       *PageTransition
+	openAction  *Action
+	closeAction *Action
+}
+
+// AddPageWithOptions adds a new page with the attributes specified in opts.
+// Apart from these attributes, it behaves like AddPage().
+//
+// The AddPageWithOptions() example demonstrates this method.
+func (f *Fpdf) AddPageWithOptions(opts PageOptions) {
+	if f.err != nil {
+		return
+	}
+	if opts.Rotate%90 != 0 {
+		f.err = fmt.Errorf("page rotation must be a multiple of 90 degrees, got %d", opts.Rotate)
+		return
+	}
+	if opts.UserUnit < 0 {
+		f.err = fmt.Errorf("invalid user unit %.2f", opts.UserUnit)
+		return
+	}
+	var trans *PageTransition
+	if opts.Transition != nil {
+		tr := *opts.Transition
+		f.transitionCheck(&tr)
+		if f.err != nil {
+			return
+		}
+		trans = &tr
+	}
+	size := opts.Size
+	if size.Wd <= 0 || size.Ht <= 0 {
+		size = f.defPageSize
+	}
+	f.AddPageFormat(opts.OrientationStr, size)
+	if f.err != nil {
+		return
+	}
+	attr := pageAttrType{
+		rotate:   (opts.Rotate%360 + 360) % 360,
+		userUnit: opts.UserUnit,
+		dur:      opts.Duration,
+		trans:    trans,
+	}
+	f.pageAttrSet(f.page, attr)
+}
+
+// SetPageTransition sets the visual effect used when a viewer in presentation
+// mode moves to the current page. See PageTransition for a description of
+// style and duration, and TransitionOptions for opts. An empty style removes
+// the transition from the page.
+//
+// The SetPageTransition() example demonstrates this method.
+func (f *Fpdf) SetPageTransition(style string, duration float64, opts TransitionOptions) {
+	if f.err != nil {
+		return
+	}
+	if f.page == 0 {
+		f.err = fmt.Errorf("a page must be added before setting its transition")
+		return
+	}
+	attr := f.pageAttrs[f.page]
+	attr.trans = nil
+	if style != "" {
+		tr := PageTransition{Style: style, Duration: duration, TransitionOptions: opts}
+		f.transitionCheck(&tr)
+		if f.err != nil {
+			return
+		}
+		attr.trans = &tr
+	}
+	f.pageAttrSet(f.page, attr)
+}
+
+// SetPageDisplayDuration sets the number of seconds the current page is
+// displayed before a viewer in presentation mode advances to the next page. A
+// value of zero removes the limit, so that the page is displayed until the
+// user advances. Combined with SetDisplayMode("fullpage", ...) and page
+// transitions, this produces self-running presentations.
+//
+// The SetPageTransition() example demonstrates this method.
+func (f *Fpdf) SetPageDisplayDuration(seconds float64) {
+	if f.err != nil {
+		return
+	}
+	if f.page == 0 {
+		f.err = fmt.Errorf("a page must be added before setting its display duration")
+		return
+	}
+	if seconds < 0 {
+		f.err = fmt.Errorf("invalid page display duration %.2f", seconds)
+		return
+	}
+	attr := f.pageAttrs[f.page]
+	attr.dur = seconds
+	f.pageAttrSet(f.page, attr)
+}
+
+// pageAttrSet records the attributes of page n and raises the PDF version of
+// the document if they require it
+func (f *Fpdf) pageAttrSet(n int, attr pageAttrType) {
+	if attr == (pageAttrType{}) {
+		delete(f.pageAttrs, n)
+		return
+	}
+	if f.pageAttrs == nil {
+		f.pageAttrs = make(map[int]pageAttrType)
+	}
+	f.pageAttrs[n] = attr
+	version := "1.3"
+	if attr.userUnit > 0 {
+		version = "1.6"
+	} else if attr.trans != nil {
+		switch attr.trans.Style {
+		case "Fly", "Push", "Cover", "Uncover", "Fade":
+			version = "1.5"
+		}
+	}
+	if f.pdfVersion < version {
+		f.pdfVersion = version
+	}
+}
+
+// transitionCheck validates tr and normalizes the case of its fields
+func (f *Fpdf) transitionCheck(tr *PageTransition) {
+	found := false
+	for _, s := range []string{"Split", "Blinds", "Box", "Wipe", "Dissolve", "Glitter",
+		"Fly", "Push", "Cover", "Uncover", "Fade", "R"} {
+		if strings.EqualFold(tr.Style, s) {
+			tr.Style = s
+			found = true
+			break
+		}
+	}
+	if !found {
+		f.err = fmt.Errorf("invalid page transition style \"%s\"", tr.Style)
+		return
+	}
+	tr.Dimension = strings.ToUpper(tr.Dimension)
+	tr.Motion = strings.ToUpper(tr.Motion)
+	directionOk := tr.Direction == 0 || tr.Direction == 270
+	if tr.Style == "Glitter" {
+		directionOk = directionOk || tr.Direction == 315
+	} else {
+		directionOk = directionOk || tr.Direction == 90 || tr.Direction == 180
+	}
+	switch {
+	case tr.Dimension != "" && tr.Dimension != "H" && tr.Dimension != "V":
+		f.err = fmt.Errorf("invalid page transition dimension \"%s\"", tr.Dimension)
+	case tr.Motion != "" && tr.Motion != "I" && tr.Motion != "O":
+		f.err = fmt.Errorf("invalid page transition motion \"%s\"", tr.Motion)
+	case !directionOk:
+		f.err = fmt.Errorf("invalid page transition direction %d for style %s", tr.Direction, tr.Style)
+	case tr.Duration < 0:
+		f.err = fmt.Errorf("invalid page transition duration %.2f", tr.Duration)
+	}
+}
+
+// putPageAttrs writes the rotation, user unit, display duration, transition
+// and action entries of page n
+func (f *Fpdf) putPageAttrs(n int) {
+	attr, ok := f.pageAttrs[n]
+	if !ok {
+		return
+	}
+	if attr.rotate != 0 {
+		f.outf("/Rotate %d", attr.rotate)
+	}
+	if attr.userUnit > 0 {
+		f.outf("/UserUnit %.4f", attr.userUnit)
+	}
+	if attr.dur > 0 {
+		f.outf("/Dur %.2f", attr.dur)
+	}
+	if tr := attr.trans; tr != nil {
+		var b fmtBuffer
+		b.printf("/Trans <</Type /Trans /S /%s", tr.Style)
+		if tr.Duration > 0 {
+			b.printf(" /D %.2f", tr.Duration)
+		}
+		switch tr.Style {
+		case "Split", "Blinds":
+			if tr.Dimension == "V" {
+				b.printf(" /Dm /V")
+			}
+		}
+		switch tr.Style {
+		case "Split", "Box", "Fly":
+			if tr.Motion == "O" {
+				b.printf(" /M /O")
+			}
+		}
+		switch tr.Style {
+		case "Wipe", "Glitter", "Fly", "Push", "Cover", "Uncover":
+			if tr.Direction != 0 {
+				b.printf(" /Di %d", tr.Direction)
+			}
+		}
+		if tr.Style == "Fly" {
+			if tr.Scale > 0 && tr.Scale != 1 {
+				b.printf(" /SS %.2f", tr.Scale)
+			}
+			if tr.Opaque {
+				b.printf(" /B true")
+			}
+		}
+		b.printf(">>")
+		f.out(b.String())
+	}
+	f.putPageActions(attr)
+}
@@ -0,0 +1,231 @@
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// InsertPageAt adds a new page to the document so that it becomes page number
+// n, where n ranges from one to one more than the number of pages. Pages at
+// and after position n move back by one. The new page becomes the current
+// page, and content is written to it until AddPage() or SetPage() is called.
+// AddPage() continues to add pages at the end of the document.
+//
+// When pages are inserted, moved or deleted, each page keeps its content,
+// size, boxes, links, attachments and annotations, and references to pages
+// are renumbered to follow them: link destinations, named destinations,
+// bookmarks, page open and close actions, the document open action, document
+// actions and the starting pages of page label ranges.
+//
+// The header function is called for the new page as it is with AddPage(). The
+// footer function is called for it immediately before the header, since the
+// page is not the last one. If n is one more than the number of pages, this
+// method is equivalent to AddPage().
+//
+// Pages cannot be inserted, moved or deleted while a layout transaction begun
+// with Begin() is open, since Rollback() only discards pages that have been
+// added at the end of the document.
+//
+// The InsertPageAt() example demonstrates this method.
+func (f *Fpdf) InsertPageAt(n int) {
+	if f.err != nil {
+		return
+	}
+	count := f.PageCount()
+	if n < 1 || n > count+1 {
+		f.err = fmt.Errorf("page %d cannot be inserted in a document of %d pages", n, count)
+		return
+	}
+	if n <= count {
+		if f.txCheck("inserted") {
+			return
+		}
+		f.pageInsertAt = n
+	}
+	f.AddPage()
+	f.pageInsertAt = 0
+}
+
+// MovePage moves page number from so that it becomes page number to. The
+// pages in between shift by one position to make room, and references to the
+// moved pages follow them as described for InsertPageAt(). The current page
+// remains the current page at its new position. Content that has already been
+// written, such as page numbers printed by the header and footer functions,
+// is not changed. As with InsertPageAt(), pages cannot be moved while a
+// layout transaction is open.
+//
+// The InsertPageAt() example demonstrates this method.
+func (f *Fpdf) MovePage(from, to int) {
+	if f.err != nil {
+		return
+	}
+	count := f.PageCount()
+	if from < 1 || from > count || to < 1 || to > count {
+		f.err = fmt.Errorf("page %d cannot be moved to %d in a document of %d pages", from, to, count)
+		return
+	}
+	if f.txCheck("moved") {
+		return
+	}
+	f.movePage(from, to)
+}
+
+// DeletePage removes page number n from the document, along with its links,
+// attachments and annotations. The pages that follow it move forward by one
+// position, and references to them follow them as described for
+// InsertPageAt(). References to the removed page itself, such as link
+// destinations, bookmarks and actions, are redirected to the top of the
+// preceding page, or the first page if there is none. If the removed page is
+// the current page, the preceding page becomes current.
+//
+// If the last page is removed, the footer function is not called again for
+// the new last page, since its footer has already been output. As with
+// InsertPageAt(), pages cannot be deleted while a layout transaction is open.
+//
+// The InsertPageAt() example demonstrates this method.
+func (f *Fpdf) DeletePage(n int) {
+	if f.err != nil {
+		return
+	}
+	count := f.PageCount()
+	if n < 1 || n > count {
+		f.err = fmt.Errorf("page %d cannot be deleted from a document of %d pages", n, count)
+		return
+	}
+	if f.txCheck("deleted") {
+		return
+	}
+	order := make([]int, 0, count-1)
+	for p := 1; p <= count; p++ {
+		if p != n {
+			order = append(order, p)
+		}
+	}
+	f.reorderPages(order)
+	if f.page == 0 && f.state == 2 {
+		f.state = 1
+	}
+}
+
+// txCheck sets an error and returns true if a layout transaction is open, in
+// which case pages cannot be rearranged. actionStr describes the operation.
+func (f *Fpdf) txCheck(actionStr string) bool {
+	if len(f.txList) > 0 {
+		f.err = fmt.Errorf("pages cannot be %s while a layout transaction is open", actionStr)
+		return true
+	}
+	return false
+}
+
+// movePage moves page from to position to without validation
+func (f *Fpdf) movePage(from, to int) {
+	count := len(f.pages) - 1
+	order := make([]int, 0, count)
+	for p := 1; p <= count; p++ {
+		if p != from {
+			order = append(order, p)
+		}
+	}
+	order = append(order[:to-1], append([]int{from}, order[to-1:]...)...)
+	f.reorderPages(order)
+}
+
+// reorderPages rearranges the pages of the document. order lists the current
+// numbers of the pages in their new sequence; pages that are not listed are
+// removed. Page content, sizes, boxes, attributes, links, attachments,
+// annotations, page labels, internal link destinations, named destinations
+// and bookmarks are carried along with their pages. Destinations and bookmarks that refer to a
+// removed page are redirected to the top of the nearest preceding page.
+func (f *Fpdf) reorderPages(order []int) {
+	count := len(f.pages) - 1
+	newPage := make([]int, count+1) // newPage[old], zero if removed
+	pages := []*bytes.Buffer{f.pages[0]}
+	pageLinks := [][]linkType{f.pageLinks[0]}
+	pageAttachments := [][]annotationAttach{f.pageAttachments[0]}
+	pageAnnots := [][]annotationType{f.pageAnnots[0]}
+	pageSizes := make(map[int]SizeType)
+	pageBoxes := make(map[int]map[string]PageBox)
+	pageAttrs := make(map[int]pageAttrType)
+	for j, old := range order {
+		n := j + 1
+		newPage[old] = n
+		pages = append(pages, f.pages[old])
+		pageLinks = append(pageLinks, f.pageLinks[old])
+		pageAttachments = append(pageAttachments, f.pageAttachments[old])
+		pageAnnots = append(pageAnnots, f.pageAnnots[old])
+		if sz, ok := f.pageSizes[old]; ok {
+			pageSizes[n] = sz
+		}
+		if boxes, ok := f.pageBoxes[old]; ok {
+			pageBoxes[n] = boxes
+		}
+		if attr, ok := f.pageAttrs[old]; ok {
+			pageAttrs[n] = attr
+		}
+	}
+	remap := func(old int) int {
+		if old < 1 || old > count {
+			return old
+		}
+		if n := newPage[old]; n > 0 {
+			return n
+		}
+		// The page was removed: use the nearest surviving page that preceded
+		// it in the original sequence, or the first page
+		for p := old - 1; p > 0; p-- {
+			if n := newPage[p]; n > 0 {
+				return n
+			}
+		}
+		if len(order) > 0 {
+			return 1
+		}
+		return 0
+	}
+	for j, l := range f.links {
+		if l.page > 0 {
+			if l.page <= count && newPage[l.page] == 0 {
+				l.y = 0
+			}
+			f.links[j].page = remap(l.page)
+			f.links[j].y = l.y
+		}
+	}
+	for name, d := range f.namedDests {
+		if d.page <= count && newPage[d.page] == 0 {
+			d.y = 0
+		}
+		d.page = remap(d.page)
+		f.namedDests[name] = d
+	}
+	removed := func(old int) bool {
+		return old <= count && newPage[old] == 0
+	}
+	for n, attr := range pageAttrs {
+		attr.openAction = actionRemap(attr.openAction, remap, removed)
+		attr.closeAction = actionRemap(attr.closeAction, remap, removed)
+		pageAttrs[n] = attr
+	}
+	f.openAction = actionRemap(f.openAction, remap, removed)
+	for key, a := range f.docActions {
+		f.docActions[key] = *actionRemap(&a, remap, removed)
+	}
+	for j, o := range f.outlines {
+		if o.p > 0 && o.p <= count && newPage[o.p] == 0 {
+			f.outlines[j].y = 0
+		}
+		f.outlines[j].p = remap(o.p)
+	}
+	f.pages = pages
+	f.pageLinks = pageLinks
+	f.pageAttachments = pageAttachments
+	f.pageAnnots = pageAnnots
+	f.pageSizes = pageSizes
+	f.pageBoxes = pageBoxes
+	f.pageAttrs = pageAttrs
+	f.pageLabels = pageLabelRemap(f.pageLabels, newPage)
+	f.page = remap(f.page)
+	if f.footerPage > 0 {
+		f.footerPage = newPage[f.footerPage]
+	}
+}
@@ -0,0 +1,207 @@
+package gofpdf
+
+import (
+	"fmt"
+)
+
+// PatternID identifies a tiling pattern created with AddTilingPattern() or
+// AddHatchPattern()
+type PatternID int
+
+// tilingType holds the content of a tiling pattern cell
+type tilingType struct {
+	wd, ht       float64 // size of cell in points
+	xStep, yStep float64 // spacing of cells in points
+	content      []byte
+}
+
+// AddTilingPattern creates a pattern that repeats a cell of width w and
+// height h, drawn by fn with the usual Fpdf methods like a template, every
+// xStep horizontally and every yStep vertically. Dimensions are in the units
+// established in New(). The content of the cell is clipped to its bounds. The
+// returned identifier is passed to SetFillPattern() and SetDrawPattern().
+//
+// The content of a cell is drawn with the fonts, colors and images of the
+// document; gradients, other patterns and transparency set within fn are not
+// supported.
+//
+// The AddTilingPattern() example demonstrates this method.
+func (f *Fpdf) AddTilingPattern(w, h, xStep, yStep float64, fn func(*Tpl)) PatternID {
+	if f.err != nil {
+		return 0
+	}
+	if w <= 0 || h <= 0 || xStep <= 0 || yStep <= 0 {
+		f.err = fmt.Errorf("invalid tiling pattern cell %.2f x %.2f with step %.2f x %.2f",
+			w, h, xStep, yStep)
+		return 0
+	}
+	t := newTpl(PointType{}, SizeType{Wd: w, Ht: h}, "P", f.unitStr, f.fontDirStr, fn, f).(*FpdfTpl)
+	// Resources used in the cell are written with those of the document
+	for name, ti := range t.images {
+		if _, found := f.images[name]; !found {
+			f.images[name] = ti
+		}
+	}
+	for _, tt := range t.templates {
+		f.templates[tt.ID()] = tt
+	}
+	if len(f.tilingList) == 0 {
+		f.tilingList = append(f.tilingList, tilingType{}) // tilingList[0] is unused
+	}
+	f.tilingList = append(f.tilingList, tilingType{wd: w * f.k, ht: h * f.k,
+		xStep: xStep * f.k, yStep: yStep * f.k, content: t.Bytes()})
+	return PatternID(len(f.tilingList) - 1)
+}
+
+// AddHatchPattern creates a pattern of lines or dots with the color specified
+// by r, g and b (0 - 255) for use with SetFillPattern() and SetDrawPattern().
+// styleStr is one of "horizontal", "vertical", "grid", "diagonal" (lines that
+// rise to the right), "backdiagonal" (lines that fall to the right),
+// "crosshatch" (both diagonals) or "dots". spacing is the distance between
+// adjacent lines, or between dot centers, and size is the line width or dot
+// diameter, both in the units established in New().
+//
+// The AddTilingPattern() example demonstrates this method.
+func (f *Fpdf) AddHatchPattern(styleStr string, spacing, size float64, r, g, b int) PatternID {
+	if f.err != nil {
+		return 0
+	}
+	if spacing <= 0 || size <= 0 {
+		f.err = fmt.Errorf("invalid hatch pattern spacing %.2f and size %.2f", spacing, size)
+		return 0
+	}
+	s := spacing
+	var lines [][4]float64
+	switch styleStr {
+	case "horizontal":
+		lines = [][4]float64{{0, s / 2, s, s / 2}}
+	case "vertical":
+		lines = [][4]float64{{s / 2, 0, s / 2, s}}
+	case "grid":
+		lines = [][4]float64{{0, s / 2, s, s / 2}, {s / 2, 0, s / 2, s}}
+	case "diagonal", "backdiagonal", "crosshatch":
+		// Lines through the corners complete the strokes clipped at the
+		// corners of adjacent cells
+		if styleStr != "backdiagonal" {
+			lines = append(lines, [4]float64{-s, s, s, -s}, [4]float64{0, s, s, 0},
+				[4]float64{0, 2 * s, 2 * s, 0})
+		}
+		if styleStr != "diagonal" {
+			lines = append(lines, [4]float64{-s, 0, s, 2 * s}, [4]float64{0, 0, s, s},
+				[4]float64{0, -s, 2 * s, s})
+		}
+		// Diagonals at 45 degrees are spaced spacing apart
+		s *= 1.4142135623730951
+		for j := range lines {
+			for k := range lines[j] {
+				lines[j][k] *= 1.4142135623730951
+			}
+		}
+	case "dots":
+	default:
+		f.err = fmt.Errorf("invalid hatch pattern style \"%s\"", styleStr)
+		return 0
+	}
+	return f.AddTilingPattern(s, s, s, s, func(t *Tpl) {
+		if styleStr == "dots" {
+			t.SetFillColor(r, g, b)
+			t.Circle(s/2, s/2, size/2, "F")
+			return
+		}
+		t.SetDrawColor(r, g, b)
+		t.SetLineWidth(size)
+		t.SetLineCapStyle("butt")
+		for _, l := range lines {
+			t.Line(l[0], l[1], l[2], l[3])
+		}
+	})
+}
+
+// SetFillPattern sets the fill color to the tiling pattern id, so that
+// subsequent filled shapes and cell backgrounds are painted with it. Cells are
+// aligned with the upper left corner of the page, according to the
+// transformation in effect when this method is called.
+//
+// The AddTilingPattern() example demonstrates this method.
+func (f *Fpdf) SetFillPattern(id PatternID) {
+	str, ok := f.tilingColor(id, "cs", "scn")
+	if !ok {
+		return
+	}
+	f.color.fill = colorType{mode: colorModePattern, str: str}
+	f.colorFlag = f.color.fill.str != f.color.text.str
+	if f.page > 0 {
+		f.out(f.color.fill.str)
+	}
+}
+
+// SetDrawPattern sets the draw color to the tiling pattern id, so that
+// subsequent lines and shape outlines are painted with it. Cells are aligned
+// as they are with SetFillPattern().
+//
+// The AddTilingPattern() example demonstrates this method.
+func (f *Fpdf) SetDrawPattern(id PatternID) {
+	str, ok := f.tilingColor(id, "CS", "SCN")
+	if !ok {
+		return
+	}
+	f.color.draw = colorType{mode: colorModePattern, str: str}
+	if f.page > 0 {
+		f.out(f.color.draw.str)
+	}
+}
+
+// tilingColor registers the placement of pattern id and returns the operators
+// that select it with the color space and color operators csStr and scnStr
+func (f *Fpdf) tilingColor(id PatternID, csStr, scnStr string) (str string, ok bool) {
+	if f.err != nil {
+		return
+	}
+	if id <= 0 || int(id) >= len(f.tilingList) {
+		f.err = fmt.Errorf("invalid tiling pattern %d", id)
+		return
+	}
+	cell := f.tilingList[id]
+	matrix := TransformMatrix{1, 0, 0, 1, 0, f.hPt - cell.ht}.multiply(f.ctm)
+	keyStr := sprintf("tiling %d %v", id, matrix)
+	pos, found := f.patternMap[keyStr]
+	if !found {
+		pos = f.patternAdd(keyStr, patternType{tiling: int(id), matrix: matrix})
+	}
+	return sprintf("/Pattern %s /P%d %s", csStr, pos, scnStr), true
+}
+
+// patternAdd adds pat to the pattern list under keyStr and returns its index
+func (f *Fpdf) patternAdd(keyStr string, pat patternType) int {
+	if f.patternMap == nil {
+		f.patternMap = make(map[string]int)
+		f.patternList = append(f.patternList, patternType{}) // patternList[0] is unused
+	}
+	pos := len(f.patternList)
+	f.patternList = append(f.patternList, pat)
+	f.patternMap[keyStr] = pos
+	return pos
+}
+
+// putTilingPattern writes the tiling pattern at index j of the pattern list.
+// The cell content uses the document resource dictionary.
+func (f *Fpdf) putTilingPattern(j int) {
+	pat := f.patternList[j]
+	cell := f.tilingList[pat.tiling]
+	m := pat.matrix
+	filter := ""
+	buffer := cell.content
+	if f.compress {
+		filter = "/Filter /FlateDecode "
+		buffer = sliceCompress(buffer)
+	}
+	f.newobj()
+	f.outf("<<%s/Type /Pattern /PatternType 1 /PaintType 1 /TilingType 1", filter)
+	f.outf("/BBox [0 0 %.5f %.5f] /XStep %.5f /YStep %.5f /Resources 2 0 R", cell.wd, cell.ht,
+		cell.xStep, cell.yStep)
+	f.outf("/Matrix [%.5f %.5f %.5f %.5f %.5f %.5f] /Length %d>>", m.A, m.B, m.C, m.D, m.E, m.F,
+		len(buffer))
+	f.putstream(buffer)
+	f.out("endobj")
+	f.patternList[j].objNum = f.n
+}
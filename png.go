@@ -108,6 +108,9 @@ func (f *Fpdf) parsepngstream(buf *bytes.Buffer, readdpi bool) (info *ImageInfoT
 				}
 			}
 			_ = buf.Next(4)
+		case "iCCP":
+			info.icc = pngICCProfile(buf.Next(n))
+			_ = buf.Next(4)
 		case "IDAT":
 			// dbg("IDAT")
 			// Read image data block
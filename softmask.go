@@ -0,0 +1,210 @@
+package gofpdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// SoftMaskID identifies a soft mask created with EndSoftMask(),
+// SoftMaskImage() or SoftMaskTemplate()
+type SoftMaskID int
+
+// captureType holds the page content and drawing state that are set aside
+// while the content of a soft mask or transparency group is drawn
+type captureType struct {
+	kind                    string // kind of soft mask, or "Group"
+	group                   groupType
+	page                    *bytes.Buffer
+	pageNum                 int
+	transformNest, clipNest int
+	x, y                    float64
+	lineWidth               float64
+	capStyle, joinStyle     int
+	dashArray               []float64
+	dashPhase               float64
+	color                   [3]colorType
+	colorFlag               bool
+	fontFamily, fontStyle   string
+	underline, strikeout    bool
+	currentFont             fontDefType
+	fontSizePt, fontSize    float64
+	isCurrentUTF8           bool
+	alpha                   float64
+	blendMode               string
+	softMask                int
+}
+
+// BeginSoftMask begins capturing the content of a soft mask. Everything drawn
+// until EndSoftMask() is called, including templates and images, is written
+// to the mask rather than to the page. kindStr is "Luminosity" or "Alpha". In
+// a luminosity mask, the brightness of the content determines the opacity of
+// the masked content: white leaves it opaque and black hides it; areas where
+// nothing is drawn are black. In an alpha mask, the opacity of the content
+// itself is used, so that shapes drawn with SetAlpha() or gradients with
+// transparent stops make the masked content partially transparent.
+//
+// The content of the mask is drawn with the current colors, line style and
+// font. Changes to these and to the current position made while drawing the
+// mask do not affect the page. Links placed within the mask are discarded.
+// Coordinates are interpreted in the user space in effect when the mask is
+// applied with ApplySoftMask(), so a mask that is applied under the
+// transformation in effect while it was drawn covers the same area of the
+// page.
+// Transformations and clipping begun within the mask must be ended before
+// EndSoftMask() is called, and pages may not be added.
+//
+// The BeginSoftMask() example demonstrates this method.
+func (f *Fpdf) BeginSoftMask(kindStr string) {
+	if f.err != nil {
+		return
+	}
+	if kindStr != "Luminosity" && kindStr != "Alpha" {
+		f.err = fmt.Errorf("unrecognized soft mask kind \"%s\"", kindStr)
+		return
+	}
+	if !f.captureBegin(&captureType{kind: kindStr}) {
+		return
+	}
+	// The mask form inherits the transformation in effect where the mask is
+	// applied but otherwise starts with the default graphics state, so the
+	// current state of the page is established in it
+	f.outf("%d J", f.capStyle)
+	f.outf("%d j", f.joinStyle)
+	f.outf("%.2f w", f.lineWidth*f.k)
+	if len(f.dashArray) > 0 {
+		f.outputDashPattern()
+	}
+	if f.fontFamily != "" {
+		f.outf("BT /F%s %.2f Tf ET", f.currentFont.i, f.fontSizePt)
+	}
+	f.out(f.color.draw.str)
+	f.out(f.color.fill.str)
+}
+
+// EndSoftMask ends the capture begun with BeginSoftMask() and returns the
+// identifier of the new soft mask, which is passed to ApplySoftMask(). The
+// drawing state in effect when BeginSoftMask() was called is restored.
+//
+// The BeginSoftMask() example demonstrates this method.
+func (f *Fpdf) EndSoftMask() SoftMaskID {
+	c, content := f.captureEnd(false)
+	if c == nil {
+		return 0
+	}
+	// The mask covers the page in the current user space
+	return SoftMaskID(f.softMaskAdd(softMaskType{kind: c.kind,
+		bbox: f.pageBBox(), content: content}))
+}
+
+// captureBegin sets aside the content and drawing state of the current page
+// and directs subsequent output to a new buffer. c holds the kind of capture
+// and receives the state. False is returned if capturing is not possible.
+func (f *Fpdf) captureBegin(c *captureType) bool {
+	if f.page == 0 {
+		f.err = fmt.Errorf("a page must be added before beginning a soft mask or transparency group")
+		return false
+	}
+	c.page, c.pageNum = f.pages[f.page], f.page
+	c.transformNest, c.clipNest = f.transformNest, f.clipNest
+	c.x, c.y = f.x, f.y
+	c.lineWidth, c.capStyle, c.joinStyle = f.lineWidth, f.capStyle, f.joinStyle
+	c.dashArray, c.dashPhase = f.dashArray, f.dashPhase
+	c.color = [3]colorType{f.color.draw, f.color.fill, f.color.text}
+	c.colorFlag = f.colorFlag
+	c.fontFamily, c.fontStyle = f.fontFamily, f.fontStyle
+	c.underline, c.strikeout = f.underline, f.strikeout
+	c.currentFont, c.isCurrentUTF8 = f.currentFont, f.isCurrentUTF8
+	c.fontSizePt, c.fontSize = f.fontSizePt, f.fontSize
+	c.alpha, c.blendMode, c.softMask = f.alpha, f.blendMode, f.softMask
+	f.captureList = append(f.captureList, c)
+	f.pages[f.page] = new(bytes.Buffer)
+	// Transparency groups and soft masks begin without transparency
+	f.alpha, f.blendMode, f.softMask = 1, "Normal", 0
+	return true
+}
+
+// captureEnd ends the most recent capture, which must be a transparency group
+// if group is true and a soft mask otherwise, and restores the content and
+// drawing state of the page. The capture and its content are returned, or nil
+// if an error occurred.
+func (f *Fpdf) captureEnd(group bool) (c *captureType, content []byte) {
+	n := len(f.captureList)
+	if n == 0 || (f.captureList[n-1].kind == "Group") != group {
+		if f.err == nil {
+			f.err = fmt.Errorf("error attempting to end soft mask or transparency group out of sequence")
+		}
+		return nil, nil
+	}
+	c = f.captureList[n-1]
+	f.captureList = f.captureList[:n-1]
+	content = f.pages[c.pageNum].Bytes()
+	f.pages[c.pageNum] = c.page
+	if f.err == nil && (f.transformNest != c.transformNest || f.clipNest != c.clipNest) {
+		f.err = fmt.Errorf("transformations and clipping begun in a soft mask or transparency group must be ended within it")
+	}
+	f.transformNest, f.clipNest = c.transformNest, c.clipNest
+	f.x, f.y = c.x, c.y
+	f.lineWidth, f.capStyle, f.joinStyle = c.lineWidth, c.capStyle, c.joinStyle
+	f.dashArray, f.dashPhase = c.dashArray, c.dashPhase
+	f.color.draw, f.color.fill, f.color.text = c.color[0], c.color[1], c.color[2]
+	f.colorFlag = c.colorFlag
+	f.fontFamily, f.fontStyle = c.fontFamily, c.fontStyle
+	f.underline, f.strikeout = c.underline, c.strikeout
+	f.currentFont, f.isCurrentUTF8 = c.currentFont, c.isCurrentUTF8
+	f.fontSizePt, f.fontSize = c.fontSizePt, c.fontSize
+	f.alpha, f.blendMode, f.softMask = c.alpha, c.blendMode, c.softMask
+	if f.err != nil {
+		return nil, nil
+	}
+	return c, content
+}
+
+// SoftMaskImage returns a luminosity soft mask made of the image imageNameStr
+// drawn at (x, y) with width w and height h, as with ImageOptions(). A
+// grayscale image is typically used, so that white areas leave the masked
+// content opaque and black areas hide it. Outside of the image, the masked
+// content is hidden.
+//
+// The BeginSoftMask() example demonstrates this method.
+func (f *Fpdf) SoftMaskImage(imageNameStr string, x, y, w, h float64) SoftMaskID {
+	f.BeginSoftMask("Luminosity")
+	f.ImageOptions(imageNameStr, x, y, w, h, false, ImageOptions{}, 0, "")
+	return f.EndSoftMask()
+}
+
+// SoftMaskTemplate returns a soft mask of kind kindStr, "Luminosity" or
+// "Alpha", made of the template t drawn at the specified corner with the
+// specified size, as with UseTemplateScaled().
+//
+// The BeginSoftMask() example demonstrates this method.
+func (f *Fpdf) SoftMaskTemplate(t Template, kindStr string, corner PointType, size SizeType) SoftMaskID {
+	f.BeginSoftMask(kindStr)
+	f.UseTemplateScaled(t, corner, size)
+	return f.EndSoftMask()
+}
+
+// ApplySoftMask applies the soft mask id to subsequent text, drawings and
+// images, which is a way to fade them gradually into the background. The mask
+// is positioned according to the transformation in effect when this method is
+// called rather than when the mask was drawn. It remains in effect until
+// another mask is applied, the end of the enclosing TransformEnd() or the end
+// of the page. An id of 0 removes the mask. Shapes filled with a gradient that
+// has transparent stops are drawn with the soft mask of the gradient instead.
+//
+// The BeginSoftMask() example demonstrates this method.
+func (f *Fpdf) ApplySoftMask(id SoftMaskID) {
+	if f.err != nil {
+		return
+	}
+	if id < 0 || (id > 0 && int(id) >= len(f.softMaskList)) {
+		f.err = fmt.Errorf("invalid soft mask %d", id)
+		return
+	}
+	if id == 0 && f.softMask == 0 {
+		return
+	}
+	f.softMask = int(id)
+	if f.page > 0 {
+		f.outf("/SM%d gs", id)
+	}
+}
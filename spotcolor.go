@@ -92,6 +92,7 @@ func (f *Fpdf) SetFillSpotColor(nameStr string, tint byte) {
 
 	clr, ok = f.getSpotColor(nameStr)
 	if ok {
+		f.color.fill.mask = 0
 		f.color.fill.mode = colorModeSpot
 		f.color.fill.spotStr = nameStr
 		f.color.fill.str = sprintf("/CS%d cs %.3f scn", clr.id, float64(byteBound(tint))/100)
@@ -180,5 +181,6 @@ func (f *Fpdf) spotColorPutResourceDict() {
 	for _, clr := range f.spotColorMap {
 		f.outf("/CS%d %d 0 R", clr.id, clr.objID)
 	}
+	f.iccPutColorSpaces()
 	f.out(">>")
 }
@@ -20,171 +20,198 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"strconv"
 	"strings"
 )
 
-var pathCmdSub *strings.Replacer
-
-func init() {
-	// Handle permitted constructions like "100L200,230"
-	pathCmdSub = strings.NewReplacer(",", " ",
-		"L", " L ", "l", " l ",
-		"C", " C ", "c", " c ",
-		"M", " M ", "m", " m ",
-		"H", " H ", "h", " h ",
-		"V", " V ", "v", " v ",
-		"Q", " Q ", "q", " q ",
-		"Z", " Z ", "z", " z ")
-}
-
 // SVGBasicSegmentType describes a single curve or position segment
 type SVGBasicSegmentType struct {
 	Cmd byte // See http://www.w3.org/TR/SVG/paths.html for path command structure
 	Arg [6]float64
 }
 
-func absolutizePath(segs []SVGBasicSegmentType) {
-	var x, y float64
-	var segPtr *SVGBasicSegmentType
-	adjust := func(pos int, adjX, adjY float64) {
-		segPtr.Arg[pos] += adjX
-		segPtr.Arg[pos+1] += adjY
-	}
-	for j, seg := range segs {
-		segPtr = &segs[j]
-		if j == 0 && seg.Cmd == 'm' {
-			segPtr.Cmd = 'M'
-		}
-		switch segPtr.Cmd {
-		case 'M':
-			x = seg.Arg[0]
-			y = seg.Arg[1]
-		case 'm':
-			adjust(0, x, y)
-			segPtr.Cmd = 'M'
-			x = segPtr.Arg[0]
-			y = segPtr.Arg[1]
-		case 'L':
-			x = seg.Arg[0]
-			y = seg.Arg[1]
-		case 'l':
-			adjust(0, x, y)
-			segPtr.Cmd = 'L'
-			x = segPtr.Arg[0]
-			y = segPtr.Arg[1]
-		case 'C':
-			x = seg.Arg[4]
-			y = seg.Arg[5]
-		case 'c':
-			adjust(0, x, y)
-			adjust(2, x, y)
-			adjust(4, x, y)
-			segPtr.Cmd = 'C'
-			x = segPtr.Arg[4]
-			y = segPtr.Arg[5]
-		case 'Q':
-			x = seg.Arg[2]
-			y = seg.Arg[3]
-		case 'q':
-			adjust(0, x, y)
-			adjust(2, x, y)
-			segPtr.Cmd = 'Q'
-			x = segPtr.Arg[2]
-			y = segPtr.Arg[3]
-		case 'H':
-			x = seg.Arg[0]
-		case 'h':
-			segPtr.Arg[0] += x
-			segPtr.Cmd = 'H'
-			x += seg.Arg[0]
-		case 'V':
-			y = seg.Arg[0]
-		case 'v':
-			segPtr.Arg[0] += y
-			segPtr.Cmd = 'V'
-			y += seg.Arg[0]
-		case 'z':
-			segPtr.Cmd = 'Z'
-		}
-	}
-}
+// pathArgCounts specifies the number of arguments of each path command
+var pathArgCounts = map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4,
+	'Q': 4, 'T': 2, 'A': 7, 'Z': 0}
 
+// pathParse converts the SVG path data pathStr to absolute segments. Smooth
+// curves and elliptical arcs are converted to cubic Bézier curves.
 func pathParse(pathStr string) (segs []SVGBasicSegmentType, err error) {
-	var seg SVGBasicSegmentType
-	var j, argJ, argCount, prevArgCount int
-	setup := func(n int) {
-		// It is not strictly necessary to clear arguments, but result may be clearer
-		// to caller
-		for j := 0; j < len(seg.Arg); j++ {
-			seg.Arg[j] = 0.0
+	var cmd, prevCmd byte
+	var x, y, startX, startY float64 // current point and start of subpath
+	var cx, cy float64               // last control point of previous curve
+	var arg [7]float64
+	pos := 0
+	skip := func() {
+		for pos < len(pathStr) && strings.IndexByte(" \t\r\n,", pathStr[pos]) >= 0 {
+			pos++
 		}
-		argJ = 0
-		argCount = n
-		prevArgCount = n
 	}
-	var str string
-	var c byte
-	pathStr = pathCmdSub.Replace(pathStr)
-	strList := strings.Fields(pathStr)
-	count := len(strList)
-	for j = 0; j < count && err == nil; j++ {
-		str = strList[j]
-		if argCount == 0 { // Look for path command or argument continuation
-			c = str[0]
-			if c == '-' || (c >= '0' && c <= '9') { // More arguments
-				if j > 0 {
-					setup(prevArgCount)
-					// Repeat previous action
-					if seg.Cmd == 'M' {
-						seg.Cmd = 'L'
-					} else if seg.Cmd == 'm' {
-						seg.Cmd = 'l'
-					}
-				} else {
-					err = fmt.Errorf("expecting SVG path command at first position, got %s", str)
-				}
+	for skip(); pos < len(pathStr) && err == nil; skip() {
+		c := pathStr[pos]
+		if c >= 'A' && c <= 'z' {
+			if _, ok := pathArgCounts[c&^0x20]; !ok {
+				return segs, fmt.Errorf("expecting SVG path command at position %d, got %c", pos, c)
 			}
+			cmd = c
+			pos++
+		} else if cmd == 0 {
+			return segs, fmt.Errorf("expecting SVG path command at first position, got %c", c)
+		} else if cmd == 'Z' || cmd == 'z' {
+			return segs, fmt.Errorf("unexpected argument at position %d following closepath", pos)
 		}
-		if err == nil {
-			if argCount == 0 {
-				seg.Cmd = str[0]
-				switch seg.Cmd {
-				case 'M', 'm': // Absolute/relative moveto: x, y
-					setup(2)
-				case 'C', 'c': // Absolute/relative Bézier curve: cx0, cy0, cx1, cy1, x1, y1
-					setup(6)
-				case 'H', 'h': // Absolute/relative horizontal line to: x
-					setup(1)
-				case 'L', 'l': // Absolute/relative lineto: x, y
-					setup(2)
-				case 'Q', 'q': // Absolute/relative quadratic curve: x0, y0, x1, y1
-					setup(4)
-				case 'V', 'v': // Absolute/relative vertical line to: y
-					setup(1)
-				case 'Z', 'z': // closepath instruction (takes no arguments)
-					segs = append(segs, seg)
-				default:
-					err = fmt.Errorf("expecting SVG path command at position %d, got %s", j, str)
+		upper := cmd &^ 0x20
+		count := pathArgCounts[upper]
+		for j := 0; j < count; j++ {
+			skip()
+			n := 0
+			if upper == 'A' && (j == 3 || j == 4) {
+				// Arc flags need not be separated from the following number
+				if pos < len(pathStr) && (pathStr[pos] == '0' || pathStr[pos] == '1') {
+					n = 1
 				}
 			} else {
-				seg.Arg[argJ], err = strconv.ParseFloat(str, 64)
-				if err == nil {
-					argJ++
-					argCount--
-					if argCount == 0 {
-						segs = append(segs, seg)
-					}
+				n = svgNumberLen(pathStr[pos:])
+			}
+			if n == 0 {
+				return segs, fmt.Errorf("expecting additional (%d) numeric arguments", count-j)
+			}
+			arg[j], err = strconv.ParseFloat(pathStr[pos:pos+n], 64)
+			if err != nil {
+				return
+			}
+			pos += n
+		}
+		if cmd != upper {
+			// Relative command
+			switch upper {
+			case 'H':
+				arg[0] += x
+			case 'V':
+				arg[0] += y
+			case 'A':
+				arg[5] += x
+				arg[6] += y
+			default:
+				for j := 0; j < count; j += 2 {
+					arg[j] += x
+					arg[j+1] += y
 				}
 			}
 		}
+		// Reflection of the last control point of a preceding curve of the same
+		// kind, otherwise the current point
+		rx, ry := x, y
+		if (upper == 'S' && (prevCmd == 'C' || prevCmd == 'S')) ||
+			(upper == 'T' && (prevCmd == 'Q' || prevCmd == 'T')) {
+			rx, ry = 2*x-cx, 2*y-cy
+		}
+		seg := SVGBasicSegmentType{Cmd: upper}
+		copy(seg.Arg[:], arg[:count])
+		switch upper {
+		case 'M':
+			startX, startY = arg[0], arg[1]
+			// Subsequent pairs are implicit lineto commands
+			cmd = 'L' | cmd&0x20
+		case 'H':
+			arg[1] = y
+		case 'V':
+			arg[1], arg[0] = arg[0], x
+		case 'C':
+			cx, cy = arg[2], arg[3]
+		case 'S':
+			seg = SVGBasicSegmentType{Cmd: 'C', Arg: [6]float64{rx, ry, arg[0], arg[1], arg[2], arg[3]}}
+			cx, cy = arg[0], arg[1]
+		case 'Q':
+			cx, cy = arg[0], arg[1]
+		case 'T':
+			seg = pathQuadToCubic(x, y, rx, ry, arg[0], arg[1])
+			cx, cy = rx, ry
+		case 'A':
+			segs = append(segs, pathArc(x, y, arg[0], arg[1], arg[2], arg[3] != 0, arg[4] != 0,
+				arg[5], arg[6])...)
+			arg[0], arg[1] = arg[5], arg[6]
+		case 'Z':
+			arg[0], arg[1] = startX, startY
+		}
+		if upper != 'A' {
+			segs = append(segs, seg)
+		}
+		// Update the current point
+		switch upper {
+		case 'C', 'S', 'Q':
+			x, y = arg[count-2], arg[count-1]
+		default:
+			x, y = arg[0], arg[1]
+		}
+		prevCmd = upper
 	}
-	if err == nil {
-		if argCount == 0 {
-			absolutizePath(segs)
-		} else {
-			err = fmt.Errorf("expecting additional (%d) numeric arguments", argCount)
+	return
+}
+
+// pathQuadToCubic returns the cubic Bézier curve equivalent to the quadratic
+// curve from (x0, y0) to (x1, y1) with control point (cx, cy)
+func pathQuadToCubic(x0, y0, cx, cy, x1, y1 float64) SVGBasicSegmentType {
+	return SVGBasicSegmentType{Cmd: 'C', Arg: [6]float64{x0 + 2*(cx-x0)/3, y0 + 2*(cy-y0)/3,
+		x1 + 2*(cx-x1)/3, y1 + 2*(cy-y1)/3, x1, y1}}
+}
+
+// pathArc returns cubic Bézier curves that approximate the SVG elliptical arc
+// from (x0, y0) to (x1, y1) with radii rx and ry, rotated by phi degrees. See
+// the implementation notes of the SVG specification for the conversion from
+// endpoint to center parameterization.
+func pathArc(x0, y0, rx, ry, phi float64, large, sweep bool, x1, y1 float64) (segs []SVGBasicSegmentType) {
+	if x0 == x1 && y0 == y1 {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return []SVGBasicSegmentType{{Cmd: 'L', Arg: [6]float64{x1, y1}}}
+	}
+	sin, cos := math.Sincos(phi * math.Pi / 180)
+	dx, dy := (x0-x1)/2, (y0-y1)/2
+	px, py := cos*dx+sin*dy, -sin*dx+cos*dy
+	// Enlarge radii that are too small to reach the end point
+	if lambda := px*px/(rx*rx) + py*py/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+	den := rx*rx*py*py + ry*ry*px*px
+	coef := math.Sqrt(math.Max(0, (rx*rx*ry*ry-den)/den))
+	if large == sweep {
+		coef = -coef
+	}
+	pcx, pcy := coef*rx*py/ry, -coef*ry*px/rx
+	cx, cy := cos*pcx-sin*pcy+(x0+x1)/2, sin*pcx+cos*pcy+(y0+y1)/2
+	theta := math.Atan2((py-pcy)/ry, (px-pcx)/rx)
+	delta := math.Atan2((-py-pcy)/ry, (-px-pcx)/rx) - theta
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+	// Each curve spans at most a quarter of the ellipse
+	n := int(math.Ceil(math.Abs(delta)/(math.Pi/2) - 1e-9))
+	if n < 1 {
+		n = 1
+	}
+	delta /= float64(n)
+	t := 4.0 / 3.0 * math.Tan(delta/4)
+	point := func(a float64) (x, y, dx, dy float64) {
+		sinA, cosA := math.Sincos(a)
+		ex, ey := rx*cosA, ry*sinA
+		dex, dey := -rx*sinA, ry*cosA
+		return cos*ex - sin*ey + cx, sin*ex + cos*ey + cy, cos*dex - sin*dey, sin*dex + cos*dey
+	}
+	ax, ay, adx, ady := point(theta)
+	for j := 1; j <= n; j++ {
+		bx, by, bdx, bdy := point(theta + float64(j)*delta)
+		if j == n {
+			bx, by = x1, y1
 		}
+		segs = append(segs, SVGBasicSegmentType{Cmd: 'C', Arg: [6]float64{ax + t*adx, ay + t*ady,
+			bx - t*bdx, by - t*bdy, bx, by}})
+		ax, ay, adx, ady = bx, by, bdx, bdy
 	}
 	return
 }
@@ -200,9 +227,11 @@ type SVGBasicType struct {
 // descriptor. Only a small subset of the SVG standard, in particular the path
 // information generated by jSignature, is supported. The returned path data
 // includes only the commands 'M' (absolute moveto: x, y), 'L' (absolute
-// lineto: x, y), 'C' (absolute cubic Bézier curve: cx0, cy0, cx1, cy1,
-// x1,y1), 'Q' (absolute quadratic Bézier curve: x0, y0, x1, y1) and 'Z'
-// (closepath).
+// lineto: x, y), 'H' (absolute horizontal lineto: x), 'V' (absolute vertical
+// lineto: y), 'C' (absolute cubic Bézier curve: cx0, cy0, cx1, cy1, x1,y1),
+// 'Q' (absolute quadratic Bézier curve: x0, y0, x1, y1) and 'Z' (closepath).
+// Smooth curves ('S' and 'T') and elliptical arcs ('A') are converted to cubic
+// Bézier curves.
 func SVGBasicParse(buf []byte) (sig SVGBasicType, err error) {
 	type pathType struct {
 		D string `xml:"d,attr"`
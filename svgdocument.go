@@ -0,0 +1,348 @@
+package gofpdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SVGDocument describes a scalable vector graphics (SVG) image for rendering
+// with DrawSVG(). It is obtained with SVGDocumentParse() or
+// SVGDocumentFileParse().
+//
+// Wd and Ht are the intrinsic size of the image in pixels, each 1/96 inch.
+// ViewBox holds the minimum x, minimum y, width and height of the region of
+// the SVG coordinate system that is mapped to the rendered area.
+type SVGDocument struct {
+	Wd, Ht  float64
+	ViewBox [4]float64
+	aspect  string                 // preserveAspectRatio attribute of the root
+	root    *svgElement            // root svg element
+	ids     map[string]*svgElement // elements by id
+}
+
+// svgElement is a node of the element tree of an SVG document
+type svgElement struct {
+	name     string
+	attrs    map[string]string
+	children []*svgElement
+}
+
+// SVGDocumentParse parses an SVG image for rendering with DrawSVG(). Unlike
+// SVGBasicParse(), this function retains the structure and presentation of
+// the image: groups, transformations, basic shapes, paths, fill and stroke
+// attributes, inline styles, reusable definitions and gradients. Text,
+// embedded images, CSS style sheets, masks, patterns and filters are not
+// supported and are ignored.
+func SVGDocumentParse(buf []byte) (doc SVGDocument, err error) {
+	dec := xml.NewDecoder(bytes.NewReader(buf))
+	// Permit the undeclared entities that some editors emit
+	dec.Strict = false
+	doc.ids = make(map[string]*svgElement)
+	var stack []*svgElement
+	for err == nil {
+		var tok xml.Token
+		tok, err = dec.Token()
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &svgElement{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				el.attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+			}
+			if id := el.attrs["id"]; id != "" {
+				doc.ids[id] = el
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if doc.root == nil {
+				doc.root = el
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if err != io.EOF {
+		return
+	}
+	err = nil
+	if doc.root == nil || doc.root.name != "svg" {
+		err = fmt.Errorf("SVG document does not have an svg root element")
+		return
+	}
+	attrs := doc.root.attrs
+	doc.aspect = attrs["preserveAspectRatio"]
+	wd, wdOk := svgLength(attrs["width"])
+	ht, htOk := svgLength(attrs["height"])
+	if s, ok := attrs["viewBox"]; ok {
+		var list []float64
+		list, err = svgNumbers(s)
+		if err != nil {
+			return
+		}
+		if len(list) != 4 || list[2] <= 0 || list[3] <= 0 {
+			err = fmt.Errorf("invalid SVG viewBox \"%s\"", s)
+			return
+		}
+		copy(doc.ViewBox[:], list)
+		switch {
+		case wdOk && htOk:
+		case wdOk:
+			ht = wd * list[3] / list[2]
+		case htOk:
+			wd = ht * list[2] / list[3]
+		default:
+			wd, ht = list[2], list[3]
+		}
+	} else if wdOk && htOk {
+		doc.ViewBox = [4]float64{0, 0, wd, ht}
+	} else {
+		err = fmt.Errorf("SVG document requires a viewBox or a width and height")
+		return
+	}
+	if wd <= 0 || ht <= 0 {
+		err = fmt.Errorf("unacceptable values for SVG extent: %.2f x %.2f", wd, ht)
+		return
+	}
+	doc.Wd, doc.Ht = wd, ht
+	return
+}
+
+// SVGDocumentFileParse parses the SVG image in the file svgFileStr for
+// rendering with DrawSVG(). See SVGDocumentParse() for details.
+//
+// The DrawSVG() example demonstrates this function.
+func SVGDocumentFileParse(svgFileStr string) (doc SVGDocument, err error) {
+	var buf []byte
+	buf, err = ioutil.ReadFile(svgFileStr)
+	if err == nil {
+		doc, err = SVGDocumentParse(buf)
+	}
+	return
+}
+
+// svgLength returns the SVG length s in pixels. ok is false if s is empty,
+// a percentage or otherwise invalid.
+func svgLength(s string) (v float64, ok bool) {
+	s = strings.TrimSpace(s)
+	scale := 1.0
+	for _, unit := range []struct {
+		suffix string
+		scale  float64
+	}{{"px", 1}, {"pt", 96.0 / 72}, {"pc", 16}, {"mm", 96 / 25.4}, {"cm", 96 / 2.54},
+		{"in", 96}, {"em", 16}, {"ex", 8}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			scale = unit.scale
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * scale, true
+}
+
+// svgNumbers returns the numbers in the SVG list s. Numbers may be separated
+// by white space, commas or, where unambiguous, nothing at all, as in
+// "10-20" and "0.5.5".
+func svgNumbers(s string) (list []float64, err error) {
+	j := 0
+	for j < len(s) && err == nil {
+		c := s[j]
+		if c == ' ' || c == ',' || c == '\t' || c == '\n' || c == '\r' {
+			j++
+			continue
+		}
+		n := svgNumberLen(s[j:])
+		if n == 0 {
+			return nil, fmt.Errorf("invalid number in SVG list \"%s\"", s)
+		}
+		var v float64
+		v, err = strconv.ParseFloat(s[j:j+n], 64)
+		list = append(list, v)
+		j += n
+	}
+	return
+}
+
+// svgNumberLen returns the length of the number at the start of s, including
+// an optional sign, fraction and exponent
+func svgNumberLen(s string) int {
+	j := 0
+	digits := func() int {
+		start := j
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		return j - start
+	}
+	if j < len(s) && (s[j] == '+' || s[j] == '-') {
+		j++
+	}
+	n := digits()
+	if j < len(s) && s[j] == '.' {
+		j++
+		n += digits()
+	}
+	if n == 0 {
+		return 0
+	}
+	if j < len(s) && (s[j] == 'e' || s[j] == 'E') {
+		k := j
+		j++
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if digits() == 0 {
+			j = k
+		}
+	}
+	return j
+}
+
+// svgTransform returns the matrix described by the SVG transform attribute
+// s, such as "translate(10 20) rotate(45)"
+func svgTransform(s string) (tm TransformMatrix, err error) {
+	tm = identityMatrix
+	s = strings.TrimSpace(s)
+	for s != "" && err == nil {
+		open := strings.IndexByte(s, '(')
+		end := strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return tm, fmt.Errorf("invalid SVG transform \"%s\"", s)
+		}
+		name := strings.TrimSpace(strings.Trim(s[:open], ", \t\r\n"))
+		var args []float64
+		args, err = svgNumbers(s[open+1 : end])
+		if err != nil {
+			return
+		}
+		s = strings.TrimSpace(s[end+1:])
+		arg := func(j int, def float64) float64 {
+			if j < len(args) {
+				return args[j]
+			}
+			return def
+		}
+		var m TransformMatrix
+		switch {
+		case name == "matrix" && len(args) == 6:
+			m = TransformMatrix{args[0], args[1], args[2], args[3], args[4], args[5]}
+		case name == "translate" && len(args) >= 1:
+			m = TransformMatrix{1, 0, 0, 1, args[0], arg(1, 0)}
+		case name == "scale" && len(args) >= 1:
+			m = TransformMatrix{args[0], 0, 0, arg(1, args[0]), 0, 0}
+		case name == "rotate" && len(args) >= 1:
+			a := args[0] * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			cos, sin := math.Cos(a), math.Sin(a)
+			m = TransformMatrix{cos, sin, -sin, cos, cx - cos*cx + sin*cy, cy - sin*cx - cos*cy}
+		case name == "skewX" && len(args) == 1:
+			m = TransformMatrix{1, 0, math.Tan(args[0] * math.Pi / 180), 1, 0, 0}
+		case name == "skewY" && len(args) == 1:
+			m = TransformMatrix{1, math.Tan(args[0] * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return tm, fmt.Errorf("invalid SVG transform \"%s\"", name)
+		}
+		// Transformations listed later are applied to points first
+		tm = m.multiply(tm)
+	}
+	return
+}
+
+// svgColor returns the RGB components of the SVG color s, which may be a
+// hexadecimal value such as "#f80" or "#ff8800", a functional value such as
+// "rgb(255, 136, 0)" or "rgb(100%, 50%, 0%)", or a color keyword
+func svgColor(s string) (r, g, b int, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return
+		}
+		return int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff), true
+	case strings.HasPrefix(s, "rgb(") && strings.HasSuffix(s, ")"):
+		parts := strings.Split(s[4:len(s)-1], ",")
+		if len(parts) != 3 {
+			return
+		}
+		var c [3]int
+		for j, p := range parts {
+			p = strings.TrimSpace(p)
+			scale := 1.0
+			if strings.HasSuffix(p, "%") {
+				p = strings.TrimSuffix(p, "%")
+				scale = 2.55
+			}
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return
+			}
+			c[j] = int(math.Max(0, math.Min(255, math.Round(v*scale))))
+		}
+		return c[0], c[1], c[2], true
+	}
+	if v, found := svgColorNames[s]; found {
+		return int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff), true
+	}
+	return
+}
+
+// svgColorNames maps the color keywords of SVG and CSS to RGB values
+var svgColorNames = map[string]uint32{
+	"aliceblue": 0xf0f8ff, "antiquewhite": 0xfaebd7, "aqua": 0x00ffff, "aquamarine": 0x7fffd4,
+	"azure": 0xf0ffff, "beige": 0xf5f5dc, "bisque": 0xffe4c4, "black": 0x000000,
+	"blanchedalmond": 0xffebcd, "blue": 0x0000ff, "blueviolet": 0x8a2be2, "brown": 0xa52a2a,
+	"burlywood": 0xdeb887, "cadetblue": 0x5f9ea0, "chartreuse": 0x7fff00, "chocolate": 0xd2691e,
+	"coral": 0xff7f50, "cornflowerblue": 0x6495ed, "cornsilk": 0xfff8dc, "crimson": 0xdc143c,
+	"cyan": 0x00ffff, "darkblue": 0x00008b, "darkcyan": 0x008b8b, "darkgoldenrod": 0xb8860b,
+	"darkgray": 0xa9a9a9, "darkgreen": 0x006400, "darkgrey": 0xa9a9a9, "darkkhaki": 0xbdb76b,
+	"darkmagenta": 0x8b008b, "darkolivegreen": 0x556b2f, "darkorange": 0xff8c00, "darkorchid": 0x9932cc,
+	"darkred": 0x8b0000, "darksalmon": 0xe9967a, "darkseagreen": 0x8fbc8f, "darkslateblue": 0x483d8b,
+	"darkslategray": 0x2f4f4f, "darkslategrey": 0x2f4f4f, "darkturquoise": 0x00ced1, "darkviolet": 0x9400d3,
+	"deeppink": 0xff1493, "deepskyblue": 0x00bfff, "dimgray": 0x696969, "dimgrey": 0x696969,
+	"dodgerblue": 0x1e90ff, "firebrick": 0xb22222, "floralwhite": 0xfffaf0, "forestgreen": 0x228b22,
+	"fuchsia": 0xff00ff, "gainsboro": 0xdcdcdc, "ghostwhite": 0xf8f8ff, "gold": 0xffd700,
+	"goldenrod": 0xdaa520, "gray": 0x808080, "green": 0x008000, "greenyellow": 0xadff2f,
+	"grey": 0x808080, "honeydew": 0xf0fff0, "hotpink": 0xff69b4, "indianred": 0xcd5c5c,
+	"indigo": 0x4b0082, "ivory": 0xfffff0, "khaki": 0xf0e68c, "lavender": 0xe6e6fa,
+	"lavenderblush": 0xfff0f5, "lawngreen": 0x7cfc00, "lemonchiffon": 0xfffacd, "lightblue": 0xadd8e6,
+	"lightcoral": 0xf08080, "lightcyan": 0xe0ffff, "lightgoldenrodyellow": 0xfafad2, "lightgray": 0xd3d3d3,
+	"lightgreen": 0x90ee90, "lightgrey": 0xd3d3d3, "lightpink": 0xffb6c1, "lightsalmon": 0xffa07a,
+	"lightseagreen": 0x20b2aa, "lightskyblue": 0x87cefa, "lightslategray": 0x778899, "lightslategrey": 0x778899,
+	"lightsteelblue": 0xb0c4de, "lightyellow": 0xffffe0, "lime": 0x00ff00, "limegreen": 0x32cd32,
+	"linen": 0xfaf0e6, "magenta": 0xff00ff, "maroon": 0x800000, "mediumaquamarine": 0x66cdaa,
+	"mediumblue": 0x0000cd, "mediumorchid": 0xba55d3, "mediumpurple": 0x9370db, "mediumseagreen": 0x3cb371,
+	"mediumslateblue": 0x7b68ee, "mediumspringgreen": 0x00fa9a, "mediumturquoise": 0x48d1cc, "mediumvioletred": 0xc71585,
+	"midnightblue": 0x191970, "mintcream": 0xf5fffa, "mistyrose": 0xffe4e1, "moccasin": 0xffe4b5,
+	"navajowhite": 0xffdead, "navy": 0x000080, "oldlace": 0xfdf5e6, "olive": 0x808000,
+	"olivedrab": 0x6b8e23, "orange": 0xffa500, "orangered": 0xff4500, "orchid": 0xda70d6,
+	"palegoldenrod": 0xeee8aa, "palegreen": 0x98fb98, "paleturquoise": 0xafeeee, "palevioletred": 0xdb7093,
+	"papayawhip": 0xffefd5, "peachpuff": 0xffdab9, "peru": 0xcd853f, "pink": 0xffc0cb,
+	"plum": 0xdda0dd, "powderblue": 0xb0e0e6, "purple": 0x800080, "rebeccapurple": 0x663399,
+	"red": 0xff0000, "rosybrown": 0xbc8f8f, "royalblue": 0x4169e1, "saddlebrown": 0x8b4513,
+	"salmon": 0xfa8072, "sandybrown": 0xf4a460, "seagreen": 0x2e8b57, "seashell": 0xfff5ee,
+	"sienna": 0xa0522d, "silver": 0xc0c0c0, "skyblue": 0x87ceeb, "slateblue": 0x6a5acd,
+	"slategray": 0x708090, "slategrey": 0x708090, "snow": 0xfffafa, "springgreen": 0x00ff7f,
+	"steelblue": 0x4682b4, "tan": 0xd2b48c, "teal": 0x008080, "thistle": 0xd8bfd8,
+	"tomato": 0xff6347, "turquoise": 0x40e0d0, "violet": 0xee82ee, "wheat": 0xf5deb3,
+	"white": 0xffffff, "whitesmoke": 0xf5f5f5, "yellow": 0xffff00, "yellowgreen": 0x9acd32,
+}
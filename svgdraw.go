@@ -0,0 +1,854 @@
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// svgPaint is the value of an SVG fill or stroke property
+type svgPaint struct {
+	none    bool
+	r, g, b int
+	ref     string // id of a gradient
+	current bool   // use the value of the color property
+}
+
+// svgStyle holds the presentation properties in effect for an SVG element
+type svgStyle struct {
+	fill, stroke  svgPaint
+	color         svgPaint
+	opacity       float64 // opacity of the element; that of containers is applied by groups
+	fillOpacity   float64
+	strokeOpacity float64
+	strokeWidth   float64
+	fillRule      string
+	lineCap       string
+	lineJoin      string
+	dashArray     []float64
+	dashOffset    float64
+	hidden        bool
+	displayNone   bool
+}
+
+// svgStopType is a color stop of an SVG gradient
+type svgStopType struct {
+	offset  float64
+	r, g, b int
+	opacity float64
+}
+
+// svgGradientType holds the resolved attributes of an SVG gradient
+type svgGradientType struct {
+	radial         bool
+	userSpace      bool
+	x1, y1, x2, y2 float64
+	cx, cy, r      float64
+	fx, fy         float64
+	transform      TransformMatrix // gradientTransform attribute
+	stops          []svgStopType
+}
+
+// svgRenderer draws the elements of an SVG document
+type svgRenderer struct {
+	f     *Fpdf
+	doc   *SVGDocument
+	v     TransformMatrix // SVG coordinates to user coordinates
+	b     TransformMatrix // SVG coordinates to page coordinates in points
+	binv  TransformMatrix // inverse of b
+	scale float64         // scale of lengths such as line widths
+	vw    float64         // width of the current viewport in SVG coordinates
+	vh    float64         // height of the current viewport in SVG coordinates
+	alpha float64         // alpha in effect outside the innermost transparency group
+	depth int             // nesting of use elements
+}
+
+// DrawSVG renders the SVG image doc, obtained with SVGDocumentParse() or
+// SVGDocumentFileParse(), in the rectangle with upper left corner (x, y),
+// width w and height h. If w or h is zero, it is calculated from the other
+// to keep the proportions of the image; if both are zero, the intrinsic size
+// of the image is used. The image is positioned within the rectangle
+// according to its preserveAspectRatio attribute and clipped to the
+// rectangle.
+//
+// The image is drawn with native PDF vector operators. The current draw and
+// fill colors, line width, line styles and alpha value are restored
+// afterward. Text, embedded images, clipping paths, masks and filters are
+// ignored. Gradient fills are drawn with SetFillGradient(); strokes that refer
+// to gradients use the color of the first stop. Groups and other containers
+// with an opacity below one are drawn with BeginTransparencyGroup() so that
+// their overlapping parts do not show through each other.
+//
+// The DrawSVG() example demonstrates this method.
+func (f *Fpdf) DrawSVG(doc *SVGDocument, x, y, w, h float64) {
+	if f.err != nil {
+		return
+	}
+	if doc == nil || doc.root == nil {
+		f.err = fmt.Errorf("SVG document is empty")
+		return
+	}
+	if f.page == 0 {
+		f.err = fmt.Errorf("a page must be added before drawing an SVG image")
+		return
+	}
+	vb := doc.ViewBox
+	switch {
+	case w <= 0 && h <= 0:
+		// Pixels are 1/96 inch
+		w, h = doc.Wd*72/96/f.k, doc.Ht*72/96/f.k
+	case w <= 0:
+		w = h * doc.Wd / doc.Ht
+	case h <= 0:
+		h = w * doc.Ht / doc.Wd
+	}
+	r := svgRenderer{f: f, doc: doc, alpha: f.alpha, vw: vb[2], vh: vb[3]}
+	r.v = svgViewport(vb, doc.aspect, x, y, w, h)
+	r.scale = math.Sqrt(r.v.A * r.v.D)
+	r.b = r.v.multiply(TransformMatrix{f.k, 0, 0, -f.k, 0, f.hPt})
+	r.binv, _ = r.b.invert()
+	color, colorFlag := f.color, f.colorFlag
+	lineWidth, capStyle, joinStyle := f.lineWidth, f.capStyle, f.joinStyle
+	dashArray, dashPhase := f.dashArray, f.dashPhase
+	alpha, blendMode := f.alpha, f.blendMode
+	curX, curY := f.x, f.y
+	// Shapes are filled with colors of their own, and the clipping paths of
+	// gradient fills must not open the soft mask of a fill gradient
+	f.color.fill.mask = 0
+	f.ClipRect(x, y, w, h, false)
+	style := svgStyle{
+		fill:          svgPaint{},
+		stroke:        svgPaint{none: true},
+		opacity:       1,
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		strokeWidth:   1,
+		fillRule:      "nonzero",
+		lineCap:       "butt",
+		lineJoin:      "miter",
+	}
+	r.drawElement(doc.root, style)
+	f.ClipEnd()
+	// The graphics state was restored by ClipEnd()
+	f.color, f.colorFlag = color, colorFlag
+	f.lineWidth, f.capStyle, f.joinStyle = lineWidth, capStyle, joinStyle
+	f.dashArray, f.dashPhase = dashArray, dashPhase
+	f.alpha, f.blendMode = alpha, blendMode
+	f.x, f.y = curX, curY
+}
+
+// svgViewport returns the transformation that maps the region vb of an SVG
+// coordinate system, given as minimum x, minimum y, width and height, into
+// the rectangle with upper left corner (x, y), width w and height h according
+// to the preserveAspectRatio attribute aspectStr
+func svgViewport(vb [4]float64, aspectStr string, x, y, w, h float64) TransformMatrix {
+	sx, sy := w/vb[2], h/vb[3]
+	tx, ty := x, y
+	fields := strings.Fields(aspectStr)
+	if len(fields) > 0 && fields[0] == "defer" {
+		fields = fields[1:]
+	}
+	align, slice := "xMidYMid", false
+	if len(fields) > 0 {
+		align = fields[0]
+		slice = len(fields) > 1 && fields[1] == "slice"
+	}
+	if align != "none" {
+		s := math.Min(sx, sy)
+		if slice {
+			s = math.Max(sx, sy)
+		}
+		sx, sy = s, s
+		if strings.Contains(align, "xMid") {
+			tx += (w - vb[2]*s) / 2
+		} else if strings.Contains(align, "xMax") {
+			tx += w - vb[2]*s
+		}
+		if strings.Contains(align, "YMid") {
+			ty += (h - vb[3]*s) / 2
+		} else if strings.Contains(align, "YMax") {
+			ty += h - vb[3]*s
+		}
+	}
+	return TransformMatrix{sx, 0, 0, sy, tx - vb[0]*sx, ty - vb[1]*sy}
+}
+
+// svgProps returns the presentation attributes of el, overridden by the
+// declarations of its style attribute
+func svgProps(el *svgElement) map[string]string {
+	props := make(map[string]string)
+	for _, name := range []string{"fill", "fill-opacity", "fill-rule", "stroke", "stroke-width",
+		"stroke-opacity", "stroke-linecap", "stroke-linejoin", "stroke-dasharray",
+		"stroke-dashoffset", "opacity", "display", "visibility", "color", "stop-color",
+		"stop-opacity"} {
+		if v, ok := el.attrs[name]; ok {
+			props[name] = v
+		}
+	}
+	for _, decl := range strings.Split(el.attrs["style"], ";") {
+		kv := strings.SplitN(decl, ":", 2)
+		if len(kv) == 2 {
+			props[strings.TrimSpace(kv[0])] = strings.TrimSpace(strings.TrimSuffix(kv[1], "!important"))
+		}
+	}
+	return props
+}
+
+// svgPaintParse returns the paint described by s. ok is false if s is not
+// valid.
+func svgPaintParse(s string) (p svgPaint, ok bool) {
+	switch {
+	case s == "none" || s == "transparent":
+		return svgPaint{none: true}, true
+	case s == "currentColor":
+		return svgPaint{current: true}, true
+	case strings.HasPrefix(s, "url("):
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return
+		}
+		ref := strings.Trim(strings.TrimSpace(s[4:end]), "'\"")
+		return svgPaint{ref: strings.TrimPrefix(ref, "#")}, true
+	}
+	p.r, p.g, p.b, ok = svgColor(s)
+	return
+}
+
+// svgOpacity returns the opacity value s, clamped to the range 0 to 1
+func svgOpacity(s string, def float64) float64 {
+	v, ok := svgLength(strings.TrimSuffix(s, "%"))
+	if !ok {
+		return def
+	}
+	if strings.HasSuffix(s, "%") {
+		v /= 100
+	}
+	return math.Max(0, math.Min(1, v))
+}
+
+// style returns the style of el given the style of its parent
+func (r *svgRenderer) style(el *svgElement, parent svgStyle) (st svgStyle) {
+	st = parent
+	st.displayNone = false
+	for name, v := range svgProps(el) {
+		if v == "inherit" {
+			continue
+		}
+		switch name {
+		case "fill", "stroke", "color":
+			p, ok := svgPaintParse(v)
+			if !ok {
+				continue
+			}
+			switch name {
+			case "fill":
+				st.fill = p
+			case "stroke":
+				st.stroke = p
+			default:
+				st.color = p
+			}
+		case "fill-opacity":
+			st.fillOpacity = svgOpacity(v, 1)
+		case "stroke-opacity":
+			st.strokeOpacity = svgOpacity(v, 1)
+		case "opacity":
+			st.opacity *= svgOpacity(v, 1)
+		case "fill-rule":
+			st.fillRule = v
+		case "stroke-width":
+			if wd, ok := svgLength(v); ok {
+				st.strokeWidth = wd
+			}
+		case "stroke-linecap":
+			st.lineCap = v
+		case "stroke-linejoin":
+			st.lineJoin = v
+		case "stroke-dasharray":
+			st.dashArray = nil
+			if v != "none" {
+				list, err := svgNumbers(strings.Replace(v, "px", "", -1))
+				if err == nil {
+					if len(list)%2 == 1 {
+						list = append(list, list...)
+					}
+					st.dashArray = list
+				}
+			}
+		case "stroke-dashoffset":
+			if offset, ok := svgLength(v); ok {
+				st.dashOffset = offset
+			}
+		case "display":
+			st.displayNone = v == "none"
+		case "visibility":
+			st.hidden = v == "hidden" || v == "collapse"
+		}
+	}
+	return
+}
+
+// drawElement draws el and its children
+func (r *svgRenderer) drawElement(el *svgElement, parent svgStyle) {
+	f := r.f
+	if f.err != nil {
+		return
+	}
+	switch el.name {
+	case "defs", "symbol", "linearGradient", "radialGradient", "clipPath", "mask", "pattern",
+		"marker", "style", "title", "desc", "metadata", "text", "image", "filter", "script":
+		return
+	}
+	st := r.style(el, parent)
+	if st.displayNone {
+		return
+	}
+	// The opacity of a container applies to its content as a whole
+	opacity := 1.0
+	switch el.name {
+	case "svg", "g", "a", "switch", "use":
+		opacity, st.opacity = st.opacity, 1
+	}
+	if opacity <= 0 {
+		return
+	}
+	tm := identityMatrix
+	if s, ok := el.attrs["transform"]; ok {
+		var err error
+		tm, err = svgTransform(s)
+		if err != nil {
+			f.err = err
+			return
+		}
+	}
+	if el.name == "use" {
+		x, _ := svgLength(el.attrs["x"])
+		y, _ := svgLength(el.attrs["y"])
+		tm = TransformMatrix{1, 0, 0, 1, x, y}.multiply(tm)
+	}
+	transformed := tm != identityMatrix
+	if transformed {
+		f.TransformBegin()
+		f.Transform(r.binv.multiply(tm).multiply(r.b))
+	}
+	r.group(opacity, func() {
+		switch el.name {
+		case "svg", "g", "a", "switch":
+			if el.name == "svg" && el != r.doc.root {
+				r.drawViewport(el, st)
+				return
+			}
+			for _, child := range el.children {
+				r.drawElement(child, st)
+			}
+		case "use":
+			href := el.attrs["href"]
+			ref, ok := r.doc.ids[strings.TrimPrefix(href, "#")]
+			switch {
+			case !ok:
+			case r.depth >= 16:
+				f.err = fmt.Errorf("SVG use elements nested too deeply at \"%s\"", href)
+			case ref.name == "symbol":
+				r.depth++
+				st = r.style(ref, st)
+				opacity := st.opacity
+				st.opacity = 1
+				r.group(opacity, func() {
+					for _, child := range ref.children {
+						r.drawElement(child, st)
+					}
+				})
+				r.depth--
+			default:
+				r.depth++
+				r.drawElement(ref, st)
+				r.depth--
+			}
+		default:
+			segs, err := r.shape(el)
+			if err != nil {
+				f.err = err
+			} else if len(segs) > 0 && !st.hidden {
+				r.paint(segs, st, el.name != "line")
+			}
+		}
+	})
+	if transformed {
+		f.TransformEnd()
+	}
+}
+
+// drawViewport draws the children of the nested svg element el in the
+// viewport it establishes. As with the root element, the viewBox is fitted to
+// the viewport according to the preserveAspectRatio attribute and the content
+// is clipped to the viewport.
+func (r *svgRenderer) drawViewport(el *svgElement, st svgStyle) {
+	f := r.f
+	x, _ := svgLength(el.attrs["x"])
+	y, _ := svgLength(el.attrs["y"])
+	// Percentages are not supported, so a missing or relative width or
+	// height fills the enclosing viewport
+	w, ok := svgLength(el.attrs["width"])
+	if !ok {
+		w = r.vw
+	}
+	h, ok := svgLength(el.attrs["height"])
+	if !ok {
+		h = r.vh
+	}
+	if w <= 0 || h <= 0 {
+		return
+	}
+	vm := TransformMatrix{1, 0, 0, 1, x, y}
+	vw, vh := w, h
+	if s, ok := el.attrs["viewBox"]; ok {
+		list, err := svgNumbers(s)
+		if err != nil || len(list) != 4 || list[2] <= 0 || list[3] <= 0 {
+			f.err = fmt.Errorf("invalid SVG viewBox \"%s\"", s)
+			return
+		}
+		vb := [4]float64{list[0], list[1], list[2], list[3]}
+		vm = svgViewport(vb, el.attrs["preserveAspectRatio"], x, y, w, h)
+		vw, vh = vb[2], vb[3]
+	}
+	x0, y0 := r.v.apply(x, y)
+	x1, y1 := r.v.apply(x+w, y+h)
+	f.ClipRect(x0, y0, x1-x0, y1-y0, false)
+	f.TransformBegin()
+	f.Transform(r.binv.multiply(vm).multiply(r.b))
+	saveW, saveH := r.vw, r.vh
+	r.vw, r.vh = vw, vh
+	for _, child := range el.children {
+		r.drawElement(child, st)
+	}
+	r.vw, r.vh = saveW, saveH
+	f.TransformEnd()
+	f.ClipEnd()
+}
+
+// shape returns the outline of the basic shape or path el as absolute
+// moveto, lineto, cubic curve and closepath segments
+func (r *svgRenderer) shape(el *svgElement) (segs []SVGBasicSegmentType, err error) {
+	num := func(name string) float64 {
+		v, _ := svgLength(el.attrs[name])
+		return v
+	}
+	seg := func(cmd byte, args ...float64) SVGBasicSegmentType {
+		s := SVGBasicSegmentType{Cmd: cmd}
+		copy(s.Arg[:], args)
+		return s
+	}
+	switch el.name {
+	case "path":
+		segs, err = pathParse(el.attrs["d"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid SVG path: %s", err)
+		}
+		segs = svgPathNormalize(segs)
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return
+		}
+		rx, rxOk := svgLength(el.attrs["rx"])
+		ry, ryOk := svgLength(el.attrs["ry"])
+		if !rxOk {
+			rx = ry
+		}
+		if !ryOk {
+			ry = rx
+		}
+		rx, ry = math.Min(math.Max(rx, 0), w/2), math.Min(math.Max(ry, 0), h/2)
+		if rx == 0 || ry == 0 {
+			return []SVGBasicSegmentType{seg('M', x, y), seg('L', x+w, y), seg('L', x+w, y+h),
+				seg('L', x, y+h), seg('Z')}, nil
+		}
+		kx, ky := svgKappa*rx, svgKappa*ry
+		segs = []SVGBasicSegmentType{
+			seg('M', x+rx, y), seg('L', x+w-rx, y),
+			seg('C', x+w-rx+kx, y, x+w, y+ry-ky, x+w, y+ry), seg('L', x+w, y+h-ry),
+			seg('C', x+w, y+h-ry+ky, x+w-rx+kx, y+h, x+w-rx, y+h), seg('L', x+rx, y+h),
+			seg('C', x+rx-kx, y+h, x, y+h-ry+ky, x, y+h-ry), seg('L', x, y+ry),
+			seg('C', x, y+ry-ky, x+rx-kx, y, x+rx, y), seg('Z'),
+		}
+	case "circle", "ellipse":
+		cx, cy := num("cx"), num("cy")
+		rx, ry := num("rx"), num("ry")
+		if el.name == "circle" {
+			rx = num("r")
+			ry = rx
+		}
+		if rx <= 0 || ry <= 0 {
+			return
+		}
+		kx, ky := svgKappa*rx, svgKappa*ry
+		segs = []SVGBasicSegmentType{
+			seg('M', cx+rx, cy),
+			seg('C', cx+rx, cy+ky, cx+kx, cy+ry, cx, cy+ry),
+			seg('C', cx-kx, cy+ry, cx-rx, cy+ky, cx-rx, cy),
+			seg('C', cx-rx, cy-ky, cx-kx, cy-ry, cx, cy-ry),
+			seg('C', cx+kx, cy-ry, cx+rx, cy-ky, cx+rx, cy),
+			seg('Z'),
+		}
+	case "line":
+		segs = []SVGBasicSegmentType{seg('M', num("x1"), num("y1")), seg('L', num("x2"), num("y2"))}
+	case "polyline", "polygon":
+		var list []float64
+		list, err = svgNumbers(el.attrs["points"])
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j+1 < len(list); j += 2 {
+			cmd := byte('L')
+			if j == 0 {
+				cmd = 'M'
+			}
+			segs = append(segs, seg(cmd, list[j], list[j+1]))
+		}
+		if el.name == "polygon" && len(segs) > 0 {
+			segs = append(segs, seg('Z'))
+		}
+	}
+	return
+}
+
+// svgLineStyles maps SVG line cap and join names to PDF line styles
+var svgLineStyles = map[string]int{"butt": 0, "square": 2, "miter": 0, "bevel": 2, "round": 1}
+
+// svgKappa is the distance, relative to the radius, of the control points of
+// a cubic Bézier curve that approximates a quarter circle
+const svgKappa = 0.5522847498
+
+// svgPathNormalize converts the horizontal and vertical lines and quadratic
+// curves of an absolute path to lines and cubic curves
+func svgPathNormalize(segs []SVGBasicSegmentType) []SVGBasicSegmentType {
+	var x, y, startX, startY float64
+	list := make([]SVGBasicSegmentType, 0, len(segs))
+	for _, seg := range segs {
+		switch seg.Cmd {
+		case 'M':
+			startX, startY = seg.Arg[0], seg.Arg[1]
+		case 'H':
+			seg = SVGBasicSegmentType{Cmd: 'L', Arg: [6]float64{seg.Arg[0], y}}
+		case 'V':
+			seg = SVGBasicSegmentType{Cmd: 'L', Arg: [6]float64{x, seg.Arg[0]}}
+		case 'Q':
+			qx, qy, ex, ey := seg.Arg[0], seg.Arg[1], seg.Arg[2], seg.Arg[3]
+			seg = SVGBasicSegmentType{Cmd: 'C', Arg: [6]float64{x + 2*(qx-x)/3, y + 2*(qy-y)/3,
+				ex + 2*(qx-ex)/3, ey + 2*(qy-ey)/3, ex, ey}}
+		}
+		switch seg.Cmd {
+		case 'M', 'L':
+			x, y = seg.Arg[0], seg.Arg[1]
+		case 'C':
+			x, y = seg.Arg[4], seg.Arg[5]
+		case 'Z':
+			x, y = startX, startY
+		}
+		list = append(list, seg)
+	}
+	return list
+}
+
+// emit adds the outline segs to the current path
+func (r *svgRenderer) emit(segs []SVGBasicSegmentType) {
+	f := r.f
+	pt := func(j int, seg SVGBasicSegmentType) (float64, float64) {
+		return r.v.apply(seg.Arg[j], seg.Arg[j+1])
+	}
+	for _, seg := range segs {
+		switch seg.Cmd {
+		case 'M':
+			f.MoveTo(pt(0, seg))
+		case 'L':
+			f.LineTo(pt(0, seg))
+		case 'C':
+			cx0, cy0 := pt(0, seg)
+			cx1, cy1 := pt(2, seg)
+			x, y := pt(4, seg)
+			f.CurveBezierCubicTo(cx0, cy0, cx1, cy1, x, y)
+		case 'Z':
+			f.ClosePath()
+		}
+	}
+}
+
+// group calls fn to draw content that is composited as a transparency group
+// with the specified opacity. fn is called directly if opacity is one.
+func (r *svgRenderer) group(opacity float64, fn func()) {
+	if opacity >= 1 {
+		fn()
+		return
+	}
+	f := r.f
+	alpha := r.alpha
+	f.BeginTransparencyGroup(false, false, opacity*alpha, f.blendMode)
+	// Drawing within the group begins fully opaque
+	r.alpha = 1
+	fn()
+	r.alpha = alpha
+	f.EndTransparencyGroup()
+}
+
+// setAlpha sets the alpha value for the following painting operation
+func (r *svgRenderer) setAlpha(alpha float64) {
+	alpha *= r.alpha
+	if math.Abs(alpha-r.f.alpha) > 0.0005 {
+		r.f.SetAlpha(alpha, r.f.blendMode)
+	}
+}
+
+// paintColor returns the color of p, resolving currentColor and using the
+// first stop of gradients, and the opacity of that stop. ok is false if
+// nothing is to be painted.
+func (r *svgRenderer) paintColor(p svgPaint, st svgStyle) (red, green, blue int, opacity float64, ok bool) {
+	if p.current {
+		p = st.color
+	}
+	switch {
+	case p.none:
+		return
+	case p.ref != "":
+		gr, found := r.gradient(p.ref)
+		if !found || len(gr.stops) == 0 {
+			return
+		}
+		s := gr.stops[0]
+		return s.r, s.g, s.b, s.opacity, true
+	}
+	return p.r, p.g, p.b, 1, true
+}
+
+// paint fills and strokes the outline segs according to st. fill is false
+// for shapes without an interior.
+func (r *svgRenderer) paint(segs []SVGBasicSegmentType, st svgStyle, fill bool) {
+	f := r.f
+	evenOdd := st.fillRule == "evenodd"
+	fillPaint := st.fill
+	if fillPaint.current {
+		fillPaint = st.color
+	}
+	fr, fg, fb, fillOpacity, fillOk := r.paintColor(fillPaint, st)
+	fill = fill && fillOk
+	sr, sg, sb, strokeOpacity, stroke := r.paintColor(st.stroke, st)
+	stroke = stroke && st.strokeWidth > 0
+	fillAlpha := st.opacity * st.fillOpacity * fillOpacity
+	strokeAlpha := st.opacity * st.strokeOpacity * strokeOpacity
+	if fill && fillPaint.ref != "" {
+		if gr, ok := r.gradient(fillPaint.ref); ok && len(gr.stops) > 1 {
+			r.setAlpha(st.opacity * st.fillOpacity)
+			f.out("q")
+			r.emit(segs)
+			f.out(strIf(evenOdd, "W* n", "W n"))
+			r.fillGradient(gr, segs)
+			f.out("Q")
+			fill = false
+		}
+	}
+	if fill && stroke && fillAlpha != strokeAlpha {
+		r.setAlpha(fillAlpha)
+		f.SetFillColor(fr, fg, fb)
+		r.emit(segs)
+		f.DrawPath(strIf(evenOdd, "F*", "F"))
+		fill = false
+	}
+	if !fill && !stroke {
+		return
+	}
+	styleStr := ""
+	if fill {
+		r.setAlpha(fillAlpha)
+		f.SetFillColor(fr, fg, fb)
+		styleStr = "F"
+	} else {
+		r.setAlpha(strokeAlpha)
+	}
+	if stroke {
+		f.SetDrawColor(sr, sg, sb)
+		if wd := st.strokeWidth * r.scale; wd != f.lineWidth {
+			f.SetLineWidth(wd)
+		}
+		if svgLineStyles[st.lineCap] != f.capStyle {
+			f.SetLineCapStyle(st.lineCap)
+		}
+		if svgLineStyles[st.lineJoin] != f.joinStyle {
+			f.SetLineJoinStyle(st.lineJoin)
+		}
+		dash := make([]float64, len(st.dashArray))
+		for j, v := range st.dashArray {
+			dash[j] = v * r.scale
+		}
+		if len(dash) > 0 || len(f.dashArray) > 0 {
+			f.SetDashPattern(dash, st.dashOffset*r.scale)
+		}
+		styleStr += "D"
+	}
+	if fill && evenOdd {
+		styleStr += "*"
+	}
+	r.emit(segs)
+	f.DrawPath(styleStr)
+}
+
+// gradient returns the gradient with the specified id, with the attributes
+// and stops it inherits from the gradients it refers to
+func (r *svgRenderer) gradient(id string) (gr svgGradientType, ok bool) {
+	var chain []*svgElement
+	for el, found := r.doc.ids[id]; found && len(chain) < 16; el, found = r.doc.ids[id] {
+		if el.name != "linearGradient" && el.name != "radialGradient" {
+			break
+		}
+		chain = append(chain, el)
+		id = strings.TrimPrefix(el.attrs["href"], "#")
+	}
+	if len(chain) == 0 {
+		return
+	}
+	attr := func(name string) (string, bool) {
+		for _, el := range chain {
+			if v, found := el.attrs[name]; found {
+				return v, true
+			}
+		}
+		return "", false
+	}
+	gr.radial = chain[0].name == "radialGradient"
+	gr.transform = identityMatrix
+	if s, found := attr("gradientTransform"); found {
+		if tm, err := svgTransform(s); err == nil {
+			gr.transform = tm
+		}
+	}
+	units, _ := attr("gradientUnits")
+	gr.userSpace = units == "userSpaceOnUse"
+	// Percentages of user space coordinates refer to the current viewport
+	coord := func(name, def string, extent float64) float64 {
+		s, found := attr(name)
+		if !found {
+			s = def
+		}
+		if strings.HasSuffix(s, "%") {
+			v, _ := svgLength(strings.TrimSuffix(s, "%"))
+			if gr.userSpace {
+				return v / 100 * extent
+			}
+			return v / 100
+		}
+		v, _ := svgLength(s)
+		return v
+	}
+	diag := math.Hypot(r.vw, r.vh) / math.Sqrt2
+	if gr.radial {
+		gr.cx = coord("cx", "50%", r.vw)
+		gr.cy = coord("cy", "50%", r.vh)
+		gr.r = coord("r", "50%", diag)
+		gr.fx, gr.fy = gr.cx, gr.cy
+		if _, found := attr("fx"); found {
+			gr.fx = coord("fx", "", r.vw)
+		}
+		if _, found := attr("fy"); found {
+			gr.fy = coord("fy", "", r.vh)
+		}
+	} else {
+		gr.x1 = coord("x1", "0%", r.vw)
+		gr.y1 = coord("y1", "0%", r.vh)
+		gr.x2 = coord("x2", "100%", r.vw)
+		gr.y2 = coord("y2", "0%", r.vh)
+	}
+	for _, el := range chain {
+		offset := 0.0
+		for _, child := range el.children {
+			if child.name != "stop" {
+				continue
+			}
+			props := svgProps(child)
+			stop := svgStopType{opacity: svgOpacity(props["stop-opacity"], 1)}
+			stop.offset = math.Max(offset, svgOpacity(child.attrs["offset"], 0))
+			offset = stop.offset
+			if c, found := props["stop-color"]; found {
+				stop.r, stop.g, stop.b, _ = svgColor(c)
+			}
+			gr.stops = append(gr.stops, stop)
+		}
+		if len(gr.stops) > 0 {
+			break
+		}
+	}
+	sort.SliceStable(gr.stops, func(i, j int) bool {
+		return gr.stops[i].offset < gr.stops[j].offset
+	})
+	return gr, true
+}
+
+// fillGradient paints gr over the bounding box of segs. The caller sets up
+// the clipping path.
+func (r *svgRenderer) fillGradient(gr svgGradientType, segs []SVGBasicSegmentType) {
+	f := r.f
+	bx, by, bw, bh := svgBounds(segs)
+	if bw <= 0 || bh <= 0 || (gr.radial && gr.r <= 0) {
+		return
+	}
+	// gm maps gradient coordinates to SVG coordinates
+	gm := gr.transform
+	if !gr.userSpace {
+		gm = gm.multiply(TransformMatrix{bw, 0, 0, bh, bx, by})
+	}
+	gmInv, ok := gm.invert()
+	if !ok {
+		return
+	}
+	g := Gradient{Radial: gr.radial, ExtendStart: true, ExtendEnd: true}
+	if gr.radial {
+		g.X1, g.Y1, g.X2, g.Y2, g.R2 = gr.fx, gr.fy, gr.cx, gr.cy, gr.r
+	} else {
+		g.X1, g.Y1, g.X2, g.Y2 = gr.x1, gr.y1, gr.x2, gr.y2
+	}
+	for _, s := range gr.stops {
+		g.Stops = append(g.Stops, GradientStop{Offset: s.offset, R: s.r, G: s.g, B: s.b, Alpha: s.opacity})
+	}
+	// Gradient coordinates are passed as user coordinates, so the space is
+	// transformed to map them to the page as SVG coordinates would be
+	u := TransformMatrix{f.k, 0, 0, -f.k, 0, f.hPt}
+	uInv, _ := u.invert()
+	fill, colorFlag := f.color.fill, f.colorFlag
+	f.TransformBegin()
+	f.Transform(uInv.multiply(gm).multiply(r.b))
+	f.SetFillGradient(g)
+	// Paint the bounding box in gradient coordinates
+	x0, y0 := math.Inf(1), math.Inf(1)
+	x1, y1 := math.Inf(-1), math.Inf(-1)
+	for _, pt := range []PointType{{bx, by}, {bx + bw, by}, {bx, by + bh}, {bx + bw, by + bh}} {
+		x, y := gmInv.apply(pt.X, pt.Y)
+		x0, x1 = math.Min(x0, x), math.Max(x1, x)
+		y0, y1 = math.Min(y0, y), math.Max(y1, y)
+	}
+	f.Rect(x0, y0, x1-x0, y1-y0, "F")
+	f.TransformEnd()
+	// The fill color was restored with the graphics state
+	f.color.fill, f.colorFlag = fill, colorFlag
+}
+
+// svgBounds returns the bounding box of the points of segs, including
+// control points
+func svgBounds(segs []SVGBasicSegmentType) (x, y, w, h float64) {
+	x0, y0 := math.Inf(1), math.Inf(1)
+	x1, y1 := math.Inf(-1), math.Inf(-1)
+	for _, seg := range segs {
+		n := 0
+		switch seg.Cmd {
+		case 'M', 'L':
+			n = 2
+		case 'C':
+			n = 6
+		}
+		for j := 0; j < n; j += 2 {
+			x0, x1 = math.Min(x0, seg.Arg[j]), math.Max(x1, seg.Arg[j])
+			y0, y1 = math.Min(y0, seg.Arg[j+1]), math.Max(y1, seg.Arg[j+1])
+		}
+	}
+	if x0 > x1 {
+		return
+	}
+	return x0, y0, x1 - x0, y1 - y0
+}
@@ -0,0 +1,186 @@
+package gofpdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// TabStop defines a position to which a tab character in text advances. Pos
+// is the distance, in the units established in New(), from the left edge of
+// the text area: the left edge of the cell for CellFormat() and MultiCell(),
+// and the left margin for Write(). In right-to-left mode, Pos is measured
+// from the right edge of the cell.
+//
+// Align specifies how the text following the tab is positioned relative to
+// Pos: "L" (the default) places its left edge at Pos, "R" places its right
+// edge at Pos, "C" centers it on Pos and "D" aligns its decimal separator on
+// Pos. DecimalStr is the separator used with "D"; an empty string is replaced
+// with ".". Text without a separator is aligned as if the separator followed
+// its last character.
+//
+// Leader, if not empty, is repeated to fill the gap before the text, for
+// example "." for the dot leaders commonly seen in tables of contents.
+type TabStop struct {
+	Pos        float64
+	Align      string
+	Leader     string
+	DecimalStr string
+}
+
+// tabPieceType is a fragment of a tabbed line positioned at page offset x
+type tabPieceType struct {
+	x      float64
+	txt    string
+	leader bool
+}
+
+// SetTabStops establishes the positions to which tab characters in Write(),
+// MultiCell() and CellFormat() advance. The stops need not be sorted. Text
+// that contains a tab character beyond the last stop has that tab rendered as
+// a space. Call this method with an empty slice to restore the default
+// behavior in which tabs are treated like spaces.
+//
+// The fragments of a line that contains tabs are positioned by the tab stops
+// rather than by the alignment of the cell, so such lines are not justified
+// by MultiCell() with the "J" alignment. In right-to-left mode, set with
+// RTL(), tab stops are measured leftward from the right edge of the cell and
+// the fragments of a line are placed from right to left, so that an "L" stop
+// aligns the start of the text that follows it and an "R" stop its end. Tab
+// stops are not supported by Write() in right-to-left mode.
+//
+// The SetTabStops() example demonstrates this method.
+func (f *Fpdf) SetTabStops(stops []TabStop) {
+	if f.err != nil {
+		return
+	}
+	list := make([]TabStop, 0, len(stops))
+	for _, stop := range stops {
+		stop.Align = strings.ToUpper(stop.Align)
+		switch stop.Align {
+		case "", "L", "R", "C", "D":
+		default:
+			f.err = fmt.Errorf("invalid tab stop alignment \"%s\"", stop.Align)
+			return
+		}
+		if stop.DecimalStr == "" {
+			stop.DecimalStr = "."
+		}
+		list = append(list, stop)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Pos < list[j].Pos })
+	f.tabStops = list
+}
+
+// GetTabStops returns the tab stops most recently set with SetTabStops(),
+// sorted by position.
+func (f *Fpdf) GetTabStops() []TabStop {
+	return append([]TabStop{}, f.tabStops...)
+}
+
+// tabActive returns true if txtStr contains a tab character that is to be
+// expanded
+func (f *Fpdf) tabActive(txtStr string) bool {
+	return len(f.tabStops) > 0 && strings.ContainsRune(txtStr, '\t')
+}
+
+// tabNext returns the position, relative to the tab origin, at which segment
+// segStr starts when the pen is at pos and the next tab is expanded. ok is
+// false if no tab stop lies beyond pos.
+func (f *Fpdf) tabNext(pos float64, segStr string) (start float64, stop TabStop, ok bool) {
+	for _, stop = range f.tabStops {
+		if stop.Pos > pos {
+			switch stop.Align {
+			case "R":
+				start = stop.Pos - f.GetStringWidth(segStr)
+			case "C":
+				start = stop.Pos - f.GetStringWidth(segStr)/2
+			case "D":
+				intStr := segStr
+				if j := strings.Index(segStr, stop.DecimalStr); j >= 0 {
+					intStr = segStr[:j]
+				}
+				start = stop.Pos - f.GetStringWidth(intStr)
+			default:
+				start = stop.Pos
+			}
+			if start < pos {
+				start = pos
+			}
+			ok = true
+			return
+		}
+	}
+	return pos, stop, false
+}
+
+// tabLayout breaks txtStr at its tab characters and positions each fragment,
+// along with any leaders, on the page. x is the page position of the first
+// fragment and origin is the page position from which tab stops are
+// measured. In right-to-left mode, x and origin are right edges from which
+// fragments and tab stops proceed leftward. The returned width is the
+// horizontal extent of all fragments.
+func (f *Fpdf) tabLayout(txtStr string, x, origin float64) (list []tabPieceType, wd float64) {
+	if f.isRTL {
+		// x and origin are right edges; the fragments are laid out on a
+		// mirrored axis and mapped back to the page below
+		x, origin = -x, -origin
+	}
+	pen := x
+	for j, segStr := range strings.Split(txtStr, "\t") {
+		start := pen
+		if j > 0 {
+			var stop TabStop
+			var ok bool
+			start, stop, ok = f.tabNext(pen-origin, segStr)
+			start += origin
+			if !ok {
+				start = pen + f.GetStringWidth(" ")
+			} else if stop.Leader != "" {
+				lw := f.GetStringWidth(stop.Leader)
+				if lw > 0 {
+					// Align leaders on a common grid so that they line up from
+					// one line to the next
+					lx := origin + math.Ceil((pen-origin)/lw)*lw
+					count := int((start - lx) / lw)
+					if count > 0 {
+						list = append(list, tabPieceType{lx, strings.Repeat(stop.Leader, count), true})
+					}
+				}
+			}
+		}
+		if segStr != "" {
+			list = append(list, tabPieceType{start, segStr, false})
+		}
+		pen = start + f.GetStringWidth(segStr)
+	}
+	wd = pen - x
+	if f.isRTL {
+		for j := range list {
+			list[j].x = -list[j].x - f.GetStringWidth(list[j].txt)
+		}
+	}
+	return
+}
+
+// tabAdvance returns the width, in units of 1/1000 of the font size, that a
+// tab character occupies when the pen is at pos relative to the tab origin
+// and the tab is followed by segStr. It is used when measuring lines that are
+// to be broken.
+func (f *Fpdf) tabAdvance(pos float64, segStr string) float64 {
+	start, _, ok := f.tabNext(pos, segStr)
+	if !ok {
+		start = pos + f.GetStringWidth(" ")
+	}
+	return (start - pos) * 1000 / f.fontSize
+}
+
+// tabSegment returns the leading portion of s up to but not including the
+// next tab or line break
+func tabSegment(s string) string {
+	if j := strings.IndexAny(s, "\t\n"); j >= 0 {
+		return s[:j]
+	}
+	return s
+}
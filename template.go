@@ -22,7 +22,10 @@ import (
 	"sort"
 )
 
-// CreateTemplate defines a new template using the current page size.
+// CreateTemplate defines a new template using the current page size. Fill
+// gradients, patterns, soft masks and mesh gradients cannot be used within a
+// template; doing so sets an error on f, as does any other error that occurs
+// within fn.
 func (f *Fpdf) CreateTemplate(fn func(*Tpl)) Template {
 	return newTpl(PointType{0, 0}, f.curPageSize, f.defOrientation, f.unitStr, f.fontDirStr, fn, f)
 }
@@ -65,7 +68,10 @@ func (f *Fpdf) UseTemplate(t Template) {
 }
 
 // UseTemplateScaled adds a template to the current page or another template,
-// using the given page coordinates.
+// using the given page coordinates. Links defined in the template are added
+// to the page at the corresponding positions; identifiers of internal links
+// in a template must be obtained with AddLink() from the document that uses
+// the template.
 func (f *Fpdf) UseTemplateScaled(t Template, corner PointType, size SizeType) {
 	if t == nil {
 		f.SetErrorf("template is nil")
@@ -109,6 +115,16 @@ func (f *Fpdf) UseTemplateScaled(t Template, corner PointType, size SizeType) {
 
 	f.outf("q %.4f 0 0 %.4f %.4f %.4f cm", scaleX, scaleY, tx, ty) // Translate
 	f.outf("/TPL%s Do Q", t.ID())
+
+	// Carry over the template's links, mapped the way its content is
+	if tpl, ok := t.(*FpdfTpl); ok && tpl.page < len(tpl.links) {
+		tplCorner, _ := t.Size()
+		tm := TransformMatrix{1, 0, 0, 1, -tplCorner.X * f.k * 2, tplCorner.Y * f.k * 2}
+		tm = tm.multiply(TransformMatrix{scaleX, 0, 0, scaleY, tx, ty}).multiply(f.ctm)
+		for _, l := range tpl.links[tpl.page] {
+			f.pageLinks[f.page] = append(f.pageLinks[f.page], f.linkTransform(l, tm))
+		}
+	}
 }
 
 // Template is an object that can be written to, then used and re-used any number of times within a document.
@@ -6,6 +6,7 @@ import (
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"io"
 )
 
 /*
@@ -37,6 +38,16 @@ func newTpl(corner PointType, size SizeType, orientationStr, unitStr, fontDirStr
 	tpl.Fpdf.AddPage()
 	fn(&tpl)
 
+	// Errors within the template halt the document it belongs to. A template's
+	// form XObject only carries fonts, images and other templates in its
+	// resources.
+	if copyFrom != nil {
+		copyFrom.SetError(tpl.Fpdf.err)
+		if tpl.Fpdf.usesPageResources() {
+			copyFrom.SetErrorf("fill gradients, patterns, soft masks and mesh gradients cannot be used in a template")
+		}
+	}
+
 	bytes := make([][]byte, len(tpl.Fpdf.pages))
 	// skip the first page as it will always be empty
 	for x := 1; x < len(bytes); x++ {
@@ -49,10 +60,24 @@ func newTpl(corner PointType, size SizeType, orientationStr, unitStr, fontDirStr
 	}
 	images := tpl.Fpdf.images
 
-	template := FpdfTpl{corner, size, bytes, images, templates, tpl.Fpdf.page}
+	template := FpdfTpl{corner, size, bytes, images, templates, tpl.Fpdf.page, tpl.Fpdf.pageLinks}
 	return &template
 }
 
+// usesPageResources reports whether patterns, soft masks or mesh shadings
+// have been registered, none of which are written to a template's resources
+func (f *Fpdf) usesPageResources() bool {
+	if len(f.patternList) > 0 || len(f.softMaskList) > 0 {
+		return true
+	}
+	for _, gr := range f.gradientList {
+		if gr.mesh != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // FpdfTpl is a concrete implementation of the Template interface.
 type FpdfTpl struct {
 	corner    PointType
@@ -61,6 +86,7 @@ type FpdfTpl struct {
 	images    map[string]*ImageInfoType
 	templates []Template
 	page      int
+	links     [][]linkType // links[page], page is 1-based
 }
 
 // ID returns the global template identifier
@@ -222,6 +248,9 @@ found_continue:
 	if err == nil {
 		err = encoder.Encode(t.page)
 	}
+	if err == nil {
+		err = encoder.Encode(templateLinksEncode(t.links))
+	}
 
 	return w.Bytes(), err
 }
@@ -264,10 +293,51 @@ func (t *FpdfTpl) GobDecode(buf []byte) error {
 	if err == nil {
 		err = decoder.Decode(&t.page)
 	}
+	if err == nil {
+		// Templates serialized before links were retained end here
+		var links [][]templateLink
+		err = decoder.Decode(&links)
+		if err == io.EOF {
+			err = nil
+		}
+		t.links = templateLinksDecode(links)
+	}
 
 	return err
 }
 
+// templateLink is the serialized form of a link on a template page
+type templateLink struct {
+	X, Y, Wd, Ht float64
+	Link         int
+	LinkStr      string
+	Quad         []float64
+}
+
+// templateLinksEncode converts the links of a template to their serialized
+// form
+func templateLinksEncode(links [][]linkType) [][]templateLink {
+	list := make([][]templateLink, len(links))
+	for j, pageLinks := range links {
+		for _, l := range pageLinks {
+			list[j] = append(list[j], templateLink{l.x, l.y, l.wd, l.ht, l.link, l.linkStr, l.quad})
+		}
+	}
+	return list
+}
+
+// templateLinksDecode converts serialized template links to their internal
+// form
+func templateLinksDecode(list [][]templateLink) [][]linkType {
+	links := make([][]linkType, len(list))
+	for j, pageLinks := range list {
+		for _, l := range pageLinks {
+			links[j] = append(links[j], linkType{l.X, l.Y, l.Wd, l.Ht, l.Link, l.LinkStr, l.Quad})
+		}
+	}
+	return links
+}
+
 // Tpl is an Fpdf used for writing a template. It has most of the facilities of
 // an Fpdf, but cannot add more pages. Tpl is used directly only during the
 // limited time a template is writable.
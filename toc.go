@@ -0,0 +1,223 @@
+package gofpdf
+
+import (
+	"fmt"
+)
+
+// TOCStyle specifies the appearance of a table of contents generated with
+// InsertTOC().
+//
+// Title, if not empty, is printed in bold at the top of the first page of the
+// table, in a font size of TitleFontSize points (16 if zero).
+//
+// FontFamily is the font family used for the title and the entries; if empty,
+// the family in effect when InsertTOC() is called is used. FontSize is the
+// size in points of the entries (12 if zero). Top level entries are printed in
+// bold. LineHt is the height of each entry in the units established in New()
+// (1.5 times the font size if zero). Indent is the distance by which each
+// level of entries is indented relative to the one above it (twice the font
+// size if zero).
+//
+// Leader is the string repeated between an entry and its page number ("." if
+// empty).
+type TOCStyle struct {
+	Title         string
+	TitleFontSize float64
+	FontFamily    string
+	FontSize      float64
+	LineHt        float64
+	Indent        float64
+	Leader        string
+}
+
+// tocEntryType is an entry in the table of contents. Its page and position
+// are those of the internal link.
+type tocEntryType struct {
+	txtStr string
+	level  int
+	link   int
+}
+
+// AddTOCEntry adds an entry to the table of contents at the current position.
+// A bookmark with the same text and level is created as well; see Bookmark().
+// The entry is listed only if a table of contents is requested with
+// InsertTOC().
+//
+// The InsertTOC() example demonstrates this method.
+func (f *Fpdf) AddTOCEntry(txtStr string, level int) {
+	if f.err != nil {
+		return
+	}
+	if f.page == 0 {
+		f.err = fmt.Errorf("a page must be added before a table of contents entry")
+		return
+	}
+	f.Bookmark(txtStr, level, -1)
+	link := f.AddLink()
+	f.SetLink(link, -1, -1)
+	f.tocEntries = append(f.tocEntries, tocEntryType{txtStr: txtStr, level: level, link: link})
+}
+
+// InsertTOC requests a table of contents listing the entries added with
+// AddTOCEntry(). The table is generated when the document is closed and is
+// inserted so that its first page becomes page number page; a value larger
+// than the number of pages appends the table to the end of the document.
+// Each entry is a link to its position in the document and shows the page
+// number it has after the table has been inserted, even if the table itself
+// occupies several pages. Entries too long for a single line are wrapped, and
+// the page number follows the last line. If page labels have been set with SetPageLabel(),
+// the label of the page is shown instead of its number.
+//
+// The table pages are produced with AddPage(), so the header and footer
+// functions are called for them. Headers and footers of pages that were
+// completed before the table is inserted are not updated; page numbers that
+// they print with PageNo() do not take the table into account.
+//
+// Calling InsertTOC() again replaces the earlier request.
+//
+// The InsertTOC() example demonstrates this method.
+func (f *Fpdf) InsertTOC(page int, style TOCStyle) {
+	if f.err != nil {
+		return
+	}
+	if page < 1 {
+		f.err = fmt.Errorf("invalid table of contents page number %d", page)
+		return
+	}
+	if style.FontFamily == "" {
+		style.FontFamily = f.fontFamily
+	}
+	if style.FontSize == 0 {
+		style.FontSize = 12
+	}
+	if style.TitleFontSize == 0 {
+		style.TitleFontSize = 16
+	}
+	if style.Leader == "" {
+		style.Leader = "."
+	}
+	f.tocPage = page
+	f.tocStyle = style
+}
+
+// tocWrite writes the table of contents on new pages. offset is the number of
+// pages the table is expected to occupy; it is added to the page numbers of
+// entries that follow the table.
+func (f *Fpdf) tocWrite(offset int) {
+	st := f.tocStyle
+	// Page labels as they will be once the table has been moved into place
+	var labels map[int]pageLabelType
+	if f.pageLabels != nil {
+		labels = make(map[int]pageLabelType, len(f.pageLabels))
+		for p, lbl := range f.pageLabels {
+			if p >= f.tocPage {
+				p += offset
+			}
+			labels[p] = lbl
+		}
+	}
+	f.AddPage()
+	if st.Title != "" {
+		f.SetFont(st.FontFamily, "B", st.TitleFontSize)
+		f.CellFormat(0, f.fontSize*1.5, st.Title, "", 1, "", false, 0, "")
+		f.Ln(f.fontSize)
+	}
+	for _, e := range f.tocEntries {
+		styleStr := ""
+		if e.level == 0 {
+			styleStr = "B"
+		}
+		f.SetFont(st.FontFamily, styleStr, st.FontSize)
+		lineHt := st.LineHt
+		if lineHt == 0 {
+			lineHt = f.fontSize * 1.5
+		}
+		indent := st.Indent
+		if indent == 0 {
+			indent = f.fontSize * 2
+		}
+		x := f.lMargin + float64(e.level)*indent
+		wd := f.w - f.rMargin - x
+		page := f.links[e.link].page
+		if page >= f.tocPage {
+			page += offset
+		}
+		numStr := pageLabelStr(labels, page)
+		// Long entries are wrapped short of the page number, which follows the
+		// last line
+		lineWd := wd - f.GetStringWidth(numStr+st.Leader+st.Leader)
+		var lines []string
+		if f.isCurrentUTF8 {
+			lines = f.SplitText(e.txtStr, lineWd)
+		} else {
+			for _, line := range f.SplitLines([]byte(e.txtStr), lineWd) {
+				lines = append(lines, string(line))
+			}
+		}
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		last := len(lines) - 1
+		for j, lineStr := range lines {
+			if j == last {
+				lineStr += "\t" + numStr
+			}
+			f.tabStops = []TabStop{{Pos: wd - f.cMargin, Align: "R", Leader: st.Leader, DecimalStr: "."}}
+			f.SetX(x)
+			f.CellFormat(wd, lineHt, lineStr, "", 1, "", false, e.link, "")
+		}
+	}
+}
+
+// tocRender generates the table of contents, if one has been requested, on
+// pages that follow the last page of the document. The table is laid out
+// until the number of pages it occupies agrees with the number assumed for
+// its page references. Assuming more pages never makes the table shorter, so
+// the number only grows until it settles.
+func (f *Fpdf) tocRender() {
+	if f.tocPage == 0 || f.err != nil {
+		return
+	}
+	last := len(f.pages) - 1
+	if f.tocPage > last+1 {
+		f.tocPage = last + 1
+	}
+	tabStops := f.tabStops
+	count := 0
+	for {
+		f.Begin()
+		f.tocWrite(count)
+		n := len(f.pages) - 1 - last
+		if n == count || f.err != nil {
+			f.Commit()
+			f.tocFirst, f.tocCount = last+1, n
+			break
+		}
+		f.Rollback()
+		if n < count {
+			f.err = fmt.Errorf("table of contents page count did not settle")
+			break
+		}
+		count = n
+	}
+	f.tabStops = tabStops
+}
+
+// tocPlace moves the pages produced by tocRender() to their requested
+// position
+func (f *Fpdf) tocPlace() {
+	if f.tocCount == 0 || f.err != nil {
+		return
+	}
+	order := make([]int, 0, len(f.pages)-1)
+	for p := 1; p < f.tocPage; p++ {
+		order = append(order, p)
+	}
+	for p := f.tocFirst; p < f.tocFirst+f.tocCount; p++ {
+		order = append(order, p)
+	}
+	for p := f.tocPage; p < f.tocFirst; p++ {
+		order = append(order, p)
+	}
+	f.reorderPages(order)
+}
@@ -0,0 +1,344 @@
+package gofpdf
+
+// txType holds the document state captured by Begin() so that it can be
+// reinstated by Rollback(). Page content, links, attachments, annotations,
+// outlines, table of contents entries and layers are only ever appended to
+// while a document is being built, so for these only lengths are recorded.
+type txType struct {
+	pageCount        int
+	pageLens         []int
+	pageLinkLens     []int
+	pageAttachLens   []int
+	pageAnnotLens    []int
+	links            []intLinkType
+	outlineCount     int
+	tocEntryCount    int
+	pageSizes        map[int]SizeType
+	pageBoxes        map[int]map[string]PageBox
+	pageAttrs        map[int]pageAttrType
+	aliasMap         map[string]string
+	namedDests       map[string]namedDestType
+	pageLabels       map[int]pageLabelType
+	openAction       *Action
+	docActions       map[string]Action
+	javascripts      map[string]string
+	viewerPrefs      *ViewerPrefs
+	tocPage          int
+	tocStyle         TOCStyle
+	layerCount       int
+	openLayerPane    bool
+	page, state      int
+	x, y, lasth, ws  float64
+	lineWidth        float64
+	w, h, wPt, hPt   float64
+	pageBreakTrigger float64
+	pageTop          float64
+	curOrientation   string
+	curPageSize      SizeType
+	fontFamily       string
+	fontStyle        string
+	underline        bool
+	strikeout        bool
+	fontSizePt       float64
+	fontSize         float64
+	currentFont      fontDefType
+	isCurrentUTF8    bool
+	color            struct{ draw, fill, text colorType }
+	colorFlag        bool
+	alpha            float64
+	overprint        overprintType
+	renderingIntent  string
+	blendMode        string
+	capStyle         int
+	joinStyle        int
+	dashArray        []float64
+	dashPhase        float64
+	clipNest         int
+	transformNest    int
+	ctm              TransformMatrix
+	ctmStack         []TransformMatrix
+	softMask         int
+	softMaskStack    []int
+	currentLayer     int
+	keepWithNext     bool
+	footerPage       int
+	err              error
+}
+
+// Begin starts a layout transaction. The state of the document, including
+// the content of all pages, the current position, font, colors, links,
+// bookmarks, actions, viewer preferences, layers, the table of contents
+// settings and error condition, is recorded so that everything written
+// afterward can be discarded with Rollback() or kept with Commit().
+// Transactions may be nested; each call to Begin() must be matched by a call
+// to Commit() or Rollback(). The document cannot be successfully output while
+// a transaction is open.
+//
+// Resources such as fonts, images and templates that are registered during a
+// transaction remain registered after a rollback. A transaction cannot be
+// begun while a soft mask or transparency group is being drawn.
+//
+// This method is demonstrated in the Measure() example.
+func (f *Fpdf) Begin() {
+	if len(f.captureList) > 0 {
+		f.SetErrorf("a layout transaction cannot begin within a soft mask or transparency group")
+		return
+	}
+	tx := &txType{
+		pageCount:        len(f.pages),
+		pageLens:         make([]int, len(f.pages)),
+		pageLinkLens:     make([]int, len(f.pageLinks)),
+		pageAttachLens:   make([]int, len(f.pageAttachments)),
+		pageAnnotLens:    make([]int, len(f.pageAnnots)),
+		links:            append([]intLinkType{}, f.links...),
+		outlineCount:     len(f.outlines),
+		tocEntryCount:    len(f.tocEntries),
+		pageSizes:        make(map[int]SizeType, len(f.pageSizes)),
+		pageBoxes:        make(map[int]map[string]PageBox, len(f.pageBoxes)),
+		pageAttrs:        make(map[int]pageAttrType, len(f.pageAttrs)),
+		aliasMap:         make(map[string]string, len(f.aliasMap)),
+		namedDests:       make(map[string]namedDestType, len(f.namedDests)),
+		openAction:       f.openAction,
+		viewerPrefs:      f.viewerPrefs,
+		tocPage:          f.tocPage,
+		tocStyle:         f.tocStyle,
+		layerCount:       len(f.layer.list),
+		openLayerPane:    f.layer.openLayerPane,
+		page:             f.page,
+		state:            f.state,
+		x:                f.x,
+		y:                f.y,
+		lasth:            f.lasth,
+		ws:               f.ws,
+		lineWidth:        f.lineWidth,
+		w:                f.w,
+		h:                f.h,
+		wPt:              f.wPt,
+		hPt:              f.hPt,
+		pageBreakTrigger: f.pageBreakTrigger,
+		pageTop:          f.pageTop,
+		curOrientation:   f.curOrientation,
+		curPageSize:      f.curPageSize,
+		fontFamily:       f.fontFamily,
+		fontStyle:        f.fontStyle,
+		underline:        f.underline,
+		strikeout:        f.strikeout,
+		fontSizePt:       f.fontSizePt,
+		fontSize:         f.fontSize,
+		currentFont:      f.currentFont,
+		isCurrentUTF8:    f.isCurrentUTF8,
+		color:            f.color,
+		colorFlag:        f.colorFlag,
+		alpha:            f.alpha,
+		overprint:        f.overprint,
+		renderingIntent:  f.renderingIntent,
+		blendMode:        f.blendMode,
+		capStyle:         f.capStyle,
+		joinStyle:        f.joinStyle,
+		dashArray:        f.dashArray,
+		dashPhase:        f.dashPhase,
+		clipNest:         f.clipNest,
+		transformNest:    f.transformNest,
+		ctm:              f.ctm,
+		ctmStack:         append([]TransformMatrix{}, f.ctmStack...),
+		softMask:         f.softMask,
+		softMaskStack:    append([]int{}, f.softMaskStack...),
+		currentLayer:     f.layer.currentLayer,
+		keepWithNext:     f.keepWithNext,
+		footerPage:       f.footerPage,
+		err:              f.err,
+	}
+	for j, pg := range f.pages {
+		tx.pageLens[j] = pg.Len()
+	}
+	for j, list := range f.pageLinks {
+		tx.pageLinkLens[j] = len(list)
+	}
+	for j, list := range f.pageAttachments {
+		tx.pageAttachLens[j] = len(list)
+	}
+	for j, list := range f.pageAnnots {
+		tx.pageAnnotLens[j] = len(list)
+	}
+	for n, sz := range f.pageSizes {
+		tx.pageSizes[n] = sz
+	}
+	for n, boxes := range f.pageBoxes {
+		mp := make(map[string]PageBox, len(boxes))
+		for t, pb := range boxes {
+			mp[t] = pb
+		}
+		tx.pageBoxes[n] = mp
+	}
+	for n, attr := range f.pageAttrs {
+		tx.pageAttrs[n] = attr
+	}
+	for alias, replacement := range f.aliasMap {
+		tx.aliasMap[alias] = replacement
+	}
+	for name, d := range f.namedDests {
+		tx.namedDests[name] = d
+	}
+	if f.pageLabels != nil {
+		tx.pageLabels = make(map[int]pageLabelType, len(f.pageLabels))
+		for p, lbl := range f.pageLabels {
+			tx.pageLabels[p] = lbl
+		}
+	}
+	if f.docActions != nil {
+		tx.docActions = make(map[string]Action, len(f.docActions))
+		for key, a := range f.docActions {
+			tx.docActions[key] = a
+		}
+	}
+	if f.javascripts != nil {
+		tx.javascripts = make(map[string]string, len(f.javascripts))
+		for name, script := range f.javascripts {
+			tx.javascripts[name] = script
+		}
+	}
+	f.txList = append(f.txList, tx)
+}
+
+// Commit ends the most recently begun layout transaction and keeps
+// everything that was written since the corresponding call to Begin(). An
+// error is set if no transaction is open.
+//
+// This method is demonstrated in the Measure() example.
+func (f *Fpdf) Commit() {
+	count := len(f.txList)
+	if count == 0 {
+		f.SetErrorf("error attempting to commit transaction out of sequence")
+		return
+	}
+	f.txList = f.txList[:count-1]
+}
+
+// Rollback ends the most recently begun layout transaction and restores the
+// document to the state it had when Begin() was called. Pages added since
+// then are removed, content written to existing pages is discarded, soft
+// masks and transparency groups begun since then and not yet ended are
+// abandoned and the error condition is reset to its earlier value. An error is
+// set if no transaction is open.
+//
+// This method is demonstrated in the Measure() example.
+func (f *Fpdf) Rollback() {
+	count := len(f.txList)
+	if count == 0 {
+		f.SetErrorf("error attempting to roll back transaction out of sequence")
+		return
+	}
+	tx := f.txList[count-1]
+	f.txList = f.txList[:count-1]
+	if len(f.captureList) > 0 {
+		// The outermost capture holds the content of the page it was begun on
+		c := f.captureList[0]
+		f.pages[c.pageNum] = c.page
+		f.captureList = nil
+	}
+	f.pages = f.pages[:tx.pageCount]
+	for j, pg := range f.pages {
+		pg.Truncate(tx.pageLens[j])
+	}
+	f.pageLinks = f.pageLinks[:len(tx.pageLinkLens)]
+	for j, ln := range tx.pageLinkLens {
+		f.pageLinks[j] = f.pageLinks[j][:ln]
+	}
+	f.pageAttachments = f.pageAttachments[:len(tx.pageAttachLens)]
+	for j, ln := range tx.pageAttachLens {
+		f.pageAttachments[j] = f.pageAttachments[j][:ln]
+	}
+	f.pageAnnots = f.pageAnnots[:len(tx.pageAnnotLens)]
+	for j, ln := range tx.pageAnnotLens {
+		f.pageAnnots[j] = f.pageAnnots[j][:ln]
+	}
+	f.links = tx.links
+	f.outlines = f.outlines[:tx.outlineCount]
+	f.tocEntries = f.tocEntries[:tx.tocEntryCount]
+	f.pageSizes = tx.pageSizes
+	f.pageBoxes = tx.pageBoxes
+	f.pageAttrs = tx.pageAttrs
+	f.aliasMap = tx.aliasMap
+	f.namedDests = tx.namedDests
+	f.pageLabels = tx.pageLabels
+	f.openAction = tx.openAction
+	f.docActions = tx.docActions
+	f.javascripts = tx.javascripts
+	f.viewerPrefs = tx.viewerPrefs
+	f.tocPage = tx.tocPage
+	f.tocStyle = tx.tocStyle
+	f.layer.list = f.layer.list[:tx.layerCount]
+	f.layer.openLayerPane = tx.openLayerPane
+	f.page = tx.page
+	f.state = tx.state
+	f.x, f.y = tx.x, tx.y
+	f.lasth = tx.lasth
+	f.ws = tx.ws
+	f.lineWidth = tx.lineWidth
+	f.w, f.h = tx.w, tx.h
+	f.wPt, f.hPt = tx.wPt, tx.hPt
+	f.pageBreakTrigger = tx.pageBreakTrigger
+	f.pageTop = tx.pageTop
+	f.widowTrigger = 0
+	f.curOrientation = tx.curOrientation
+	f.curPageSize = tx.curPageSize
+	f.fontFamily = tx.fontFamily
+	f.fontStyle = tx.fontStyle
+	f.underline = tx.underline
+	f.strikeout = tx.strikeout
+	f.fontSizePt = tx.fontSizePt
+	f.fontSize = tx.fontSize
+	f.currentFont = tx.currentFont
+	f.isCurrentUTF8 = tx.isCurrentUTF8
+	f.color = tx.color
+	f.colorFlag = tx.colorFlag
+	f.alpha = tx.alpha
+	f.overprint = tx.overprint
+	f.renderingIntent = tx.renderingIntent
+	f.blendMode = tx.blendMode
+	f.capStyle = tx.capStyle
+	f.joinStyle = tx.joinStyle
+	f.dashArray = tx.dashArray
+	f.dashPhase = tx.dashPhase
+	f.clipNest = tx.clipNest
+	f.transformNest = tx.transformNest
+	f.ctm = tx.ctm
+	f.ctmStack = tx.ctmStack
+	f.softMask = tx.softMask
+	f.softMaskStack = tx.softMaskStack
+	f.layer.currentLayer = tx.currentLayer
+	f.keepWithNext = tx.keepWithNext
+	f.footerPage = tx.footerPage
+	f.err = tx.err
+}
+
+// Measure runs fn, which typically writes content with methods such as
+// MultiCell() or CellFormat(), in dry-run mode and reports the extent of the
+// output. No trace of fn's output remains in the document afterward; see
+// Begin() and Rollback() for details about what is restored.
+//
+// pages is the number of pages the output spans, one if it fits on the
+// current page. height is the vertical distance, in the units established in
+// New(), that the current position advanced. If the output spans more than
+// one page, height is the sum of the space used on each page, measured from
+// the top margin on all but the first page and to the page break threshold
+// on all but the last page.
+//
+// Any error condition set by fn is discarded along with its output.
+func (f *Fpdf) Measure(fn func()) (height float64, pages int) {
+	if f.err != nil {
+		return
+	}
+	page, y, trigger := f.page, f.y, f.pageBreakTrigger
+	f.Begin()
+	fn()
+	pages = f.page - page + 1
+	if pages <= 1 {
+		pages = 1
+		height = f.y - y
+	} else {
+		height = (trigger - y) + float64(pages-2)*(trigger-f.tMargin) + (f.y - f.tMargin)
+	}
+	f.Rollback()
+	return
+}
@@ -0,0 +1,176 @@
+package gofpdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ViewerPrefs specifies how a PDF viewer presents the document and how it
+// prints it. It is used with SetViewerPreferences(). Zero values leave the
+// corresponding setting to the viewer.
+//
+// HideToolbar, HideMenubar and HideWindowUI hide the viewer's toolbars, menu
+// bar and other user interface elements. FitWindow resizes the document
+// window to fit the first page, CenterWindow centers the window on the screen
+// and DisplayDocTitle shows the title set with SetTitle() in the window title
+// bar rather than the file name.
+//
+// PageMode determines which panel, if any, is shown when the document is
+// opened: "UseNone", "UseOutlines" (bookmarks), "UseThumbs" (page
+// thumbnails), "FullScreen", "UseOC" (layers) or "UseAttachments". If empty,
+// "UseOutlines" is used for documents with bookmarks. NonFullScreenPageMode
+// is the mode, one of "UseNone", "UseOutlines", "UseThumbs" or "UseOC", used
+// upon leaving full screen mode.
+//
+// Direction is the reading order, "L2R" or "R2L", which affects how pages are
+// laid out side by side.
+//
+// PrintScaling is "None" to print pages at their actual size or "AppDefault".
+// Duplex is "Simplex", "DuplexFlipShortEdge" or "DuplexFlipLongEdge".
+// PickTrayByPDFSize selects the paper tray by page size. PrintPageRange lists
+// the first and last pages, one-based, of each range of pages preselected in
+// the print dialog. NumCopies is the number of copies preselected in the print
+// dialog.
+type ViewerPrefs struct {
+	HideToolbar           bool
+	HideMenubar           bool
+	HideWindowUI          bool
+	FitWindow             bool
+	CenterWindow          bool
+	DisplayDocTitle       bool
+	PageMode              string
+	NonFullScreenPageMode string
+	Direction             string
+	PrintScaling          string
+	Duplex                string
+	PickTrayByPDFSize     bool
+	PrintPageRange        []int
+	NumCopies             int
+}
+
+// SetViewerPreferences specifies how a PDF viewer presents and prints the
+// document. It complements SetDisplayMode(), which sets the initial zoom and
+// page layout. Some preferences raise the PDF version of the document: to 1.5
+// for the "UseOC" page mode, to 1.6 for PrintScaling and the "UseAttachments"
+// page mode, and to 1.7 for Duplex, PickTrayByPDFSize, PrintPageRange and
+// NumCopies. A page mode set here takes precedence over the layer pane
+// requested with OpenLayerPane() and the bookmark pane that is otherwise shown
+// for documents with bookmarks.
+//
+// The SetViewerPreferences() example demonstrates this method.
+func (f *Fpdf) SetViewerPreferences(prefs ViewerPrefs) {
+	if f.err != nil {
+		return
+	}
+	check := func(name, value string, list ...string) bool {
+		if value == "" {
+			return true
+		}
+		for _, s := range list {
+			if value == s {
+				return true
+			}
+		}
+		f.err = fmt.Errorf("invalid viewer preference %s \"%s\"", name, value)
+		return false
+	}
+	if !check("PageMode", prefs.PageMode, "UseNone", "UseOutlines", "UseThumbs", "FullScreen", "UseOC", "UseAttachments") ||
+		!check("NonFullScreenPageMode", prefs.NonFullScreenPageMode, "UseNone", "UseOutlines", "UseThumbs", "UseOC") ||
+		!check("Direction", prefs.Direction, "L2R", "R2L") ||
+		!check("PrintScaling", prefs.PrintScaling, "None", "AppDefault") ||
+		!check("Duplex", prefs.Duplex, "Simplex", "DuplexFlipShortEdge", "DuplexFlipLongEdge") {
+		return
+	}
+	if len(prefs.PrintPageRange)%2 != 0 {
+		f.err = fmt.Errorf("print page range must contain pairs of page numbers")
+		return
+	}
+	for j := 0; j < len(prefs.PrintPageRange); j += 2 {
+		first, last := prefs.PrintPageRange[j], prefs.PrintPageRange[j+1]
+		if first < 1 || last < first {
+			f.err = fmt.Errorf("invalid print page range %d-%d", first, last)
+			return
+		}
+	}
+	if prefs.NumCopies < 0 {
+		f.err = fmt.Errorf("invalid number of copies %d", prefs.NumCopies)
+		return
+	}
+	version := "1.3"
+	if prefs.PageMode == "UseOC" || prefs.NonFullScreenPageMode == "UseOC" {
+		version = "1.5"
+	}
+	if prefs.PrintScaling != "" || prefs.PageMode == "UseAttachments" {
+		version = "1.6"
+	}
+	if prefs.Duplex != "" || prefs.PickTrayByPDFSize || len(prefs.PrintPageRange) > 0 || prefs.NumCopies > 0 {
+		version = "1.7"
+	}
+	if f.pdfVersion < version {
+		f.pdfVersion = version
+	}
+	prefs.PrintPageRange = append([]int{}, prefs.PrintPageRange...)
+	f.viewerPrefs = &prefs
+}
+
+// putPageMode writes the /PageMode entry of the catalog. A page mode set with
+// SetViewerPreferences() takes precedence over the layer pane, which takes
+// precedence over the bookmark pane.
+func (f *Fpdf) putPageMode() {
+	modeStr := ""
+	switch {
+	case f.viewerPrefs != nil && f.viewerPrefs.PageMode != "":
+		modeStr = f.viewerPrefs.PageMode
+	case f.layer.openLayerPane && len(f.layer.list) > 0:
+		modeStr = "UseOC"
+	case len(f.outlines) > 0:
+		modeStr = "UseOutlines"
+	}
+	if modeStr != "" {
+		f.out("/PageMode /" + modeStr)
+	}
+}
+
+// putViewerPrefs writes the /ViewerPreferences entry of the catalog
+func (f *Fpdf) putViewerPrefs() {
+	vp := f.viewerPrefs
+	if vp == nil {
+		return
+	}
+	var b fmtBuffer
+	flag := func(name string, set bool) {
+		if set {
+			b.printf("/%s true ", name)
+		}
+	}
+	name := func(key, value string) {
+		if value != "" {
+			b.printf("/%s /%s ", key, value)
+		}
+	}
+	flag("HideToolbar", vp.HideToolbar)
+	flag("HideMenubar", vp.HideMenubar)
+	flag("HideWindowUI", vp.HideWindowUI)
+	flag("FitWindow", vp.FitWindow)
+	flag("CenterWindow", vp.CenterWindow)
+	flag("DisplayDocTitle", vp.DisplayDocTitle)
+	name("NonFullScreenPageMode", vp.NonFullScreenPageMode)
+	name("Direction", vp.Direction)
+	name("PrintScaling", vp.PrintScaling)
+	name("Duplex", vp.Duplex)
+	flag("PickTrayByPDFSize", vp.PickTrayByPDFSize)
+	if len(vp.PrintPageRange) > 0 {
+		list := make([]string, len(vp.PrintPageRange))
+		for j, p := range vp.PrintPageRange {
+			list[j] = sprintf("%d", p-1)
+		}
+		b.printf("/PrintPageRange [%s] ", strings.Join(list, " "))
+	}
+	if vp.NumCopies > 0 {
+		b.printf("/NumCopies %d ", vp.NumCopies)
+	}
+	if b.Len() > 0 {
+		s := b.String()
+		f.outf("/ViewerPreferences <<%s>>", s[:len(s)-1])
+	}
+}
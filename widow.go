@@ -0,0 +1,144 @@
+package gofpdf
+
+import (
+	"math"
+	"strings"
+)
+
+// SetWidowOrphanControl sets the minimum number of lines of a paragraph
+// written with MultiCell() that may be left alone at the bottom of a page (an
+// orphan) or carried over to the top of the next page (a widow). Before a
+// paragraph is written, it is measured with SplitText() or SplitLines(). If
+// the automatic page break would strand fewer than minLines lines at the
+// bottom of the current page, the whole paragraph is moved to the next page.
+// If it would carry fewer than minLines lines to the next page, the break is
+// moved up so that minLines lines are carried over. The lines that fit on a
+// page are counted from the position that follows the header of the current
+// page. A value of zero, the
+// default, disables this control.
+//
+// Page breaks requested in this way are subject to the function established
+// with SetAcceptPageBreakFunc(), so multiple column layouts continue to work.
+//
+// The SetWidowOrphanControl() example demonstrates this method.
+func (f *Fpdf) SetWidowOrphanControl(minLines int) {
+	if minLines < 0 {
+		minLines = 0
+	}
+	f.widowOrphan = minLines
+}
+
+// GetWidowOrphanControl returns the minimum number of lines set with
+// SetWidowOrphanControl().
+func (f *Fpdf) GetWidowOrphanControl() int {
+	return f.widowOrphan
+}
+
+// SetKeepWithNext marks the next block written with MultiCell(), typically a
+// heading, as one that must not be separated from the block that follows it.
+// If the marked block fits on the current page but the first lines of the
+// following block do not, the marked block is moved to the next page. The
+// following block is assumed to use the same line height as the marked block,
+// and the number of its lines that must accompany the marked block is the
+// value set with SetWidowOrphanControl(), or one if that control is disabled.
+// The flag is cleared once the block has been written.
+//
+// The SetWidowOrphanControl() example demonstrates this method.
+func (f *Fpdf) SetKeepWithNext(keep bool) {
+	f.keepWithNext = keep
+}
+
+// multiCellLineCount returns the number of lines MultiCell() would produce
+// for txtStr in a cell of width w. ok is false if the text cannot be
+// measured with the current font.
+func (f *Fpdf) multiCellLineCount(txtStr string, w float64) (count int, ok bool) {
+	if f.isCurrentUTF8 {
+		cw := f.currentFont.Cw
+		for _, r := range txtStr {
+			if int(r) >= len(cw) {
+				return
+			}
+		}
+		count = len(f.SplitText(strings.Replace(txtStr, "\r", "", -1), w))
+	} else {
+		count = len(f.SplitLines([]byte(txtStr), w))
+	}
+	if count == 0 {
+		count = 1
+	}
+	ok = true
+	return
+}
+
+// multiCellKeep applies widow, orphan and keep-with-next control to a block
+// of text about to be written by MultiCell() in a cell of width w with line
+// height h. It either issues a page break before the block or lowers the page
+// break trigger so that the automatic break occurs early enough to carry the
+// required number of lines to the next page.
+func (f *Fpdf) multiCellKeep(w, h float64, txtStr string) {
+	keepNext := f.keepWithNext
+	f.keepWithNext = false
+	if (f.widowOrphan == 0 && !keepNext) || h <= 0 || f.inHeader || f.inFooter || f.currentFont.Name == "" {
+		return
+	}
+	n, ok := f.multiCellLineCount(txtStr, w)
+	if !ok {
+		return
+	}
+	minLines := f.widowOrphan
+	// Lines available on the current page and on a fresh page, below its header
+	avail := int(math.Floor((f.pageBreakTrigger-f.y)/h + 1e-9))
+	if avail < 0 {
+		avail = 0
+	}
+	capacity := int(math.Floor((f.pageBreakTrigger-f.pageTop)/h + 1e-9))
+	// Already at the top of a page, moving the block gains nothing
+	atTop := avail >= capacity
+	breakBefore := false
+	switch {
+	case avail >= n:
+		if keepNext {
+			next := minLines
+			if next < 1 {
+				next = 1
+			}
+			breakBefore = avail < n+next
+		}
+	case minLines > 0:
+		if avail < minLines {
+			breakBefore = true
+		} else if capacity > 0 {
+			rem := (n - avail) % capacity
+			if rem > 0 && rem < minLines {
+				first := avail - (minLines - rem)
+				if first < minLines {
+					breakBefore = true
+				} else {
+					f.widowTrigger = f.pageBreakTrigger
+					f.pageBreakTrigger = f.y + (float64(first)+0.5)*h
+				}
+			}
+		}
+	}
+	if breakBefore && !atTop && f.acceptPageBreak() {
+		x := f.x
+		f.AddPageFormat(f.curOrientation, f.curPageSize)
+		f.x = x
+	}
+}
+
+// widowRestore reinstates the page break trigger that was lowered by
+// multiCellKeep()
+func (f *Fpdf) widowRestore() {
+	if f.widowTrigger > 0 {
+		f.pageBreakTrigger = f.widowTrigger
+		f.widowTrigger = 0
+	}
+}
+
+// pageBreakAccepted returns true if the application accepts an automatic page
+// break. Any page break trigger lowered for widow control is restored first.
+func (f *Fpdf) pageBreakAccepted() bool {
+	f.widowRestore()
+	return f.acceptPageBreak()
+}